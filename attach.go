@@ -0,0 +1,167 @@
+package trysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/engines"
+	"github.com/blainemoser/TrySql/reporters"
+	"github.com/blainemoser/TrySql/runtimes"
+)
+
+// Labels set on every sandbox's container, so that other processes can find it and attach to it
+const (
+	labelEngine    = "trysql.engine"
+	labelReadiness = "trysql.readiness"
+)
+
+// ErrNoSandbox is returned when attaching and there is no sandbox to attach to
+var ErrNoSandbox = errors.New("no sandbox found")
+
+// Attach connects to a sandbox started by another process. It is found by the name flag or,
+// without one, is the only sandbox there is. The other flags are those of Initialise, except
+// that the engine, image and readiness strategy are read from the container itself
+func Attach(args []string) (*TrySql, error) {
+	return AttachContext(context.Background(), args)
+}
+
+// AttachContext is Attach, giving up when ctx is cancelled
+func AttachContext(ctx context.Context, args []string) (*TrySql, error) {
+	confs, err := configs.Load(args)
+	if err != nil {
+		return nil, err
+	}
+	err = confs.Validate()
+	if err != nil {
+		return nil, err
+	}
+	rt, err := runtimes.New(confs)
+	if err != nil {
+		return nil, err
+	}
+	container, err := findSandbox(ctx, rt, confs.GetName())
+	if err != nil {
+		return nil, err
+	}
+	inspect, err := rt.Inspect(ctx, container.ID)
+	if err != nil {
+		return nil, err
+	}
+	// args is copied so the container's settings are not written into the caller's backing array
+	confs, err = configs.Load(append(
+		append([]string{}, args...),
+		"--engine", container.Labels[labelEngine],
+		"--image", inspect.Config.Image,
+		"--readiness", container.Labels[labelReadiness],
+	))
+	if err != nil {
+		return nil, err
+	}
+	engine, err := engines.New(confs)
+	if err != nil {
+		return nil, err
+	}
+	reporter, err := reporters.New(confs, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	ts := &TrySql{
+		runtime:  rt,
+		engine:   engine,
+		image:    inspect.Config.Image,
+		name:     strings.TrimPrefix(inspect.Name, "/"),
+		reporter: reporter,
+		Configs:  confs,
+	}
+	ts.reporter = reporters.Redact(reporter, ts.secrets)
+	err = ts.adopt(ctx, inspect)
+	if err != nil {
+		return nil, ts.redact(err)
+	}
+	err = ts.initRuntime(ctx)
+	if err != nil {
+		return nil, ts.redact(err)
+	}
+	return ts, nil
+}
+
+// adopt takes on the identity of an existing container, whose credentials and port are the
+// ones it was created with rather than this process's. The credentials are read from their
+// files, which needs the container to be running
+func (ts *TrySql) adopt(ctx context.Context, inspect *docker.ContainerJSON) error {
+	ts.hash = inspect.ID
+	port := publishedPort(ts.engine, inspect)
+	if port > 0 {
+		ts.hostPort = port
+	}
+	if inspect.Config == nil {
+		return nil
+	}
+	ts.password = ""
+	ts.user = inspect.Config.Labels[labelUser]
+	ts.userPassword = ""
+	if inspect.State == nil || !inspect.State.Running {
+		return nil
+	}
+	var err error
+	ts.password, err = ts.readSecret(ctx, engines.PasswordFile)
+	if err != nil {
+		return err
+	}
+	if len(ts.user) > 0 {
+		ts.userPassword, err = ts.readSecret(ctx, userPasswordFile)
+	}
+	return err
+}
+
+// findSandbox looks through the labelled containers, stopped ones included
+func findSandbox(ctx context.Context, rt runtimes.Runtime, name string) (docker.ContainerSummary, error) {
+	containers, err := rt.List(ctx, true, map[string][]string{"label": {labelEngine}})
+	if err != nil {
+		return docker.ContainerSummary{}, err
+	}
+	if len(name) > 0 {
+		for _, container := range containers {
+			for _, containerName := range container.Names {
+				if strings.TrimPrefix(containerName, "/") == name {
+					return container, nil
+				}
+			}
+		}
+		return docker.ContainerSummary{}, fmt.Errorf("%w named '%s'", ErrNoSandbox, name)
+	}
+	switch len(containers) {
+	case 0:
+		return docker.ContainerSummary{}, ErrNoSandbox
+	case 1:
+		return containers[0], nil
+	}
+	names := make([]string, len(containers))
+	for i, container := range containers {
+		names[i] = strings.TrimPrefix(strings.Join(container.Names, ","), "/")
+	}
+	return docker.ContainerSummary{}, fmt.Errorf(
+		"there are %d sandboxes (%s), choose one with --name",
+		len(containers),
+		strings.Join(names, ", "),
+	)
+}
+
+func publishedPort(engine engines.Engine, inspect *docker.ContainerJSON) int {
+	if inspect.NetworkSettings == nil {
+		return 0
+	}
+	for _, binding := range inspect.NetworkSettings.Ports[engine.Port()] {
+		port, err := strconv.Atoi(binding.HostPort)
+		if err == nil {
+			return port
+		}
+	}
+	return 0
+}
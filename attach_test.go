@@ -0,0 +1,72 @@
+package trysql
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/engines"
+	"github.com/blainemoser/TrySql/runtimes"
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestAdopt(t *testing.T) {
+	defer utils.HandelPanic(t)
+	for _, name := range []string{"mysql", "mariadb", "postgres"} {
+		engine, err := engines.New(testConfigs(t, "--engine", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		inspect := &docker.ContainerJSON{
+			ID:    "0123456789abcdef",
+			State: &docker.ContainerState{Running: true},
+			Config: &docker.ContainerConfig{
+				Env:    append([]string{"PATH=/usr/bin"}, engine.Env()...),
+				Labels: map[string]string{labelUser: "app"},
+			},
+			NetworkSettings: &docker.NetworkSettings{
+				Ports: map[string][]docker.PortBinding{
+					engine.Port(): {{HostIP: "0.0.0.0", HostPort: "49153"}},
+				},
+			},
+		}
+		ts := &TrySql{runtime: &fileRuntime{files: map[string]string{
+			engines.PasswordFile: "from-file",
+			userPasswordFile:     "app-pass",
+		}}, engine: engine, password: "mine", hostPort: 6603}
+		err = ts.adopt(context.Background(), inspect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts.Password() != "from-file" {
+			t.Errorf("expected the %s container's password to be read from its file, got '%s'", name, ts.Password())
+		}
+		if ts.AppUser() != "app" || ts.AppPassword() != "app-pass" {
+			t.Errorf("expected the %s container's user to be adopted, got '%s' and '%s'", name, ts.AppUser(), ts.AppPassword())
+		}
+		if ts.HostPortStr() != "49153" || ts.ContainerID() != inspect.ID {
+			t.Errorf("expected the %s container's port and ID to be adopted, got %s and %s", name, ts.HostPortStr(), ts.ContainerID())
+		}
+		inspect.State.Running = false
+		ts = &TrySql{engine: engine}
+		err = ts.adopt(context.Background(), inspect)
+		if err != nil || ts.AppUser() != "app" {
+			t.Errorf("expected a stopped %s container to be adopted without reading its files, got %v", name, err)
+		}
+	}
+}
+
+// fileRuntime serves the files of a container to cat, the only command it runs
+type fileRuntime struct {
+	runtimes.Runtime
+	files map[string]string
+}
+
+func (f *fileRuntime) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (*docker.ExecResult, error) {
+	content, ok := f.files[cmd[len(cmd)-1]]
+	if cmd[0] != "cat" || !ok {
+		return &docker.ExecResult{ExitCode: 1, Stderr: "cat: " + cmd[len(cmd)-1] + ": No such file or directory"}, nil
+	}
+	return &docker.ExecResult{Stdout: content}, nil
+}
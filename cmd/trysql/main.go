@@ -0,0 +1,295 @@
+// Command trysql starts, inspects and tears down sandbox databases from the shell
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	trysql "github.com/blainemoser/TrySql"
+	"github.com/blainemoser/TrySql/configs"
+)
+
+// Exit codes, so that scripts can tell a missing or unready sandbox from a failure
+const (
+	exitOK        = 0
+	exitFailure   = 1
+	exitUsage     = 2
+	exitNoSandbox = 3
+	exitNotReady  = 4
+)
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type subcommand struct {
+	usage string
+	run   func(c *cli, ctx context.Context, flags, positional []string) int
+}
+
+var subcommands = map[string]subcommand{
+	"up":     {"start a sandbox and print how to connect to it", (*cli).up},
+	"config": {"print the settings in effect and where each came from", (*cli).config},
+	"down":   {"stop and remove a sandbox", (*cli).down},
+	"status": {"report whether a sandbox is ready, exiting 4 when it is not", (*cli).status},
+	"query":  {"run the SQL given as an argument, or read from stdin", (*cli).query},
+	"logs":   {"print the server's log, the last --tail lines of it", (*cli).logs},
+	"shell":  {"open the engine's client in the sandbox", (*cli).shell},
+	"prune":  {"remove the sandboxes whose owner process exited or whose --ttl ran out", (*cli).prune},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	c := &cli{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+	code := c.run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+func (c *cli) run(ctx context.Context, args []string) int {
+	if len(args) < 1 {
+		c.usage(c.stderr)
+		return exitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "--help":
+		c.usage(c.stdout)
+		return exitOK
+	}
+	command, ok := subcommands[args[0]]
+	if !ok {
+		fmt.Fprintf(c.stderr, "unknown command '%s'\n\n", args[0])
+		c.usage(c.stderr)
+		return exitUsage
+	}
+	for _, arg := range args[1:] {
+		if arg == "-h" || arg == "--help" {
+			fmt.Fprintf(c.stdout, "usage: trysql %s [flags]\n\n%s\n\nFlags:\n%s", args[0], command.usage, configs.Usage())
+			return exitOK
+		}
+		if arg == "--" {
+			break
+		}
+	}
+	flags, positional := splitArgs(args[1:])
+	return command.run(c, ctx, flags, positional)
+}
+
+func (c *cli) usage(out io.Writer) {
+	fmt.Fprintln(out, "usage: trysql <command> [flags]")
+	fmt.Fprintln(out)
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(writer, "  %s\t%s\n", name, subcommands[name].usage)
+	}
+	writer.Flush()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands other than up find the sandbox by --name, or use the only one there is")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	fmt.Fprint(out, configs.Usage())
+}
+
+func (c *cli) up(ctx context.Context, flags, positional []string) int {
+	if len(positional) > 0 {
+		return c.unexpected(positional)
+	}
+	// Initialise reads the process's own arguments when given none, which here include the command
+	if len(flags) < 1 {
+		flags = []string{"--reporter", "auto"}
+	}
+	// The sandbox is meant to outlive this command, so prune must not take it for an orphan
+	confs, err := configs.Load(flags)
+	if err != nil {
+		return c.fail(err)
+	}
+	if !confs.IsSet("lifetime") {
+		// flags may share its backing array with the positional arguments splitArgs cut it from
+		flags = append(append([]string{}, flags...), "--lifetime", trysql.LifetimeDetached)
+	}
+	ts, err := trysql.InitialiseContext(ctx, flags)
+	if err != nil {
+		return c.fail(err)
+	}
+	writer := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "name\t%s\n", ts.Name())
+	fmt.Fprintf(writer, "engine\t%s\n", ts.Engine())
+	fmt.Fprintf(writer, "image\t%s\n", ts.ImageStatus())
+	fmt.Fprintf(writer, "address\t%s\n", ts.Address())
+	fmt.Fprintf(writer, "password\t%s\n", ts.Password())
+	if len(ts.AppUser()) > 0 {
+		fmt.Fprintf(writer, "user\t%s\n", ts.AppUser())
+		fmt.Fprintf(writer, "user password\t%s\n", ts.AppPassword())
+	}
+	fmt.Fprintf(writer, "uri\t%s\n", ts.URI())
+	fmt.Fprintf(writer, "jdbc\t%s\n", ts.JDBCURL())
+	fmt.Fprintf(writer, "connect\t%s\n", ts.ConnectCommand())
+	writer.Flush()
+	return exitOK
+}
+
+// config prints the flags layered over the config file and the environment, as up would use them
+func (c *cli) config(ctx context.Context, flags, positional []string) int {
+	if len(positional) > 0 {
+		return c.unexpected(positional)
+	}
+	confs, err := configs.Load(flags)
+	if err != nil {
+		return c.fail(err)
+	}
+	fmt.Fprint(c.stdout, confs.String())
+	return c.fail(confs.Validate())
+}
+
+func (c *cli) down(ctx context.Context, flags, positional []string) int {
+	if len(positional) > 0 {
+		return c.unexpected(positional)
+	}
+	ts, err := trysql.AttachContext(ctx, flags)
+	if err != nil {
+		return c.fail(err)
+	}
+	return c.fail(ts.TearDownContext(ctx))
+}
+
+func (c *cli) status(ctx context.Context, flags, positional []string) int {
+	if len(positional) > 0 {
+		return c.unexpected(positional)
+	}
+	ts, err := trysql.AttachContext(ctx, flags)
+	if err != nil {
+		return c.fail(err)
+	}
+	status, err := ts.Status(ctx)
+	if err != nil {
+		return c.fail(err)
+	}
+	fmt.Fprintf(c.stdout, "%s\t%s\t%s\t%s\n", ts.Name(), ts.Engine(), ts.Address(), status)
+	if status != "healthy" {
+		return exitNotReady
+	}
+	return exitOK
+}
+
+func (c *cli) query(ctx context.Context, flags, positional []string) int {
+	var script io.Reader = c.stdin
+	if len(positional) > 0 {
+		script = strings.NewReader(strings.Join(positional, " "))
+	}
+	ts, err := trysql.AttachContext(ctx, flags)
+	if err != nil {
+		return c.fail(err)
+	}
+	result, err := ts.RunScript(ctx, script)
+	fmt.Fprint(c.stdout, result)
+	return c.fail(err)
+}
+
+func (c *cli) logs(ctx context.Context, flags, positional []string) int {
+	if len(positional) > 0 {
+		return c.unexpected(positional)
+	}
+	ts, err := trysql.AttachContext(ctx, flags)
+	if err != nil {
+		return c.fail(err)
+	}
+	logs, err := ts.Logs(ctx, ts.Configs.GetTail())
+	fmt.Fprint(c.stdout, logs)
+	return c.fail(err)
+}
+
+func (c *cli) prune(ctx context.Context, flags, positional []string) int {
+	if len(positional) > 0 {
+		return c.unexpected(positional)
+	}
+	pruned, err := trysql.PruneContext(ctx, flags)
+	writer := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	for _, sandbox := range pruned {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", sandbox.Name, sandbox.ID, sandbox.Reason, strings.Join(sandbox.Volumes, ","))
+	}
+	writer.Flush()
+	return c.fail(err)
+}
+
+// shell hands the terminal to the client, exiting with the client's own exit code
+func (c *cli) shell(ctx context.Context, flags, positional []string) int {
+	if len(positional) > 0 {
+		return c.unexpected(positional)
+	}
+	ts, err := trysql.AttachContext(ctx, flags)
+	if err != nil {
+		return c.fail(err)
+	}
+	args := ts.ShellCommand()
+	client := exec.Command(args[0], args[1:]...)
+	client.Stdin = c.stdin
+	client.Stdout = c.stdout
+	client.Stderr = c.stderr
+	err = client.Run()
+	exit := &exec.ExitError{}
+	if errors.As(err, &exit) {
+		return exit.ExitCode()
+	}
+	return c.fail(err)
+}
+
+// fail prints err and returns the exit code for it, which is zero when there is no error
+func (c *cli) fail(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	fmt.Fprintln(c.stderr, "trysql: "+err.Error())
+	if errors.Is(err, trysql.ErrNoSandbox) {
+		return exitNoSandbox
+	}
+	return exitFailure
+}
+
+func (c *cli) unexpected(positional []string) int {
+	fmt.Fprintf(c.stderr, "trysql: unexpected arguments '%s'\n", strings.Join(positional, " "))
+	return exitUsage
+}
+
+// splitArgs separates the flags and their values from the arguments following them. Flags
+// taking several values take every argument up to the next flag
+func splitArgs(args []string) ([]string, []string) {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--":
+			return args[:i], args[i+1:]
+		case !strings.HasPrefix(args[i], "-"):
+			return args[:i], args[i:]
+		case strings.Contains(args[i], "="):
+			continue
+		}
+		flag, _ := configs.Lookup(strings.TrimLeft(args[i], "-"))
+		if !flag.Multi {
+			i++
+			continue
+		}
+		for i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+		}
+	}
+	return args, nil
+}
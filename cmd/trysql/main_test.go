@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestSplitArgs(t *testing.T) {
+	defer utils.HandelPanic(t)
+	expects := []struct {
+		args       []string
+		flags      string
+		positional string
+	}{
+		{[]string{"--name", "db", "SELECT 1"}, "--name db", "SELECT 1"},
+		{[]string{"--name=db", "SELECT", "1"}, "--name=db", "SELECT 1"},
+		{[]string{"--tail", "20"}, "--tail 20", ""},
+		{[]string{"--name", "db", "--", "-- comment"}, "--name db", "-- comment"},
+		{[]string{"SELECT 1"}, "", "SELECT 1"},
+		{[]string{"--databases", "app", "app_test", "--port", "3307"}, "--databases app app_test --port 3307", ""},
+	}
+	for _, expect := range expects {
+		flags, positional := splitArgs(expect.args)
+		if strings.Join(flags, " ") != expect.flags || strings.Join(positional, " ") != expect.positional {
+			t.Errorf(
+				"expected %v to split into '%s' and '%s', got '%s' and '%s'",
+				expect.args,
+				expect.flags,
+				expect.positional,
+				strings.Join(flags, " "),
+				strings.Join(positional, " "),
+			)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	defer utils.HandelPanic(t)
+	c, stdout, stderr := testCLI()
+	code := c.run(context.Background(), []string{})
+	if code != exitUsage {
+		t.Errorf("expected exit code %d without a command, got %d", exitUsage, code)
+	}
+	code = c.run(context.Background(), []string{"sideways"})
+	if code != exitUsage || !strings.Contains(stderr.String(), "unknown command 'sideways'") {
+		t.Errorf("expected an unknown command to be refused, got %d: %s", code, stderr.String())
+	}
+	code = c.run(context.Background(), []string{"--help"})
+	if code != exitOK {
+		t.Errorf("expected help to succeed, got %d", code)
+	}
+	for name := range subcommands {
+		if !strings.Contains(stdout.String(), "  "+name+" ") {
+			t.Errorf("expected help to list '%s', got %s", name, stdout.String())
+		}
+	}
+}
+
+func TestCommandHelp(t *testing.T) {
+	defer utils.HandelPanic(t)
+	c, stdout, _ := testCLI()
+	code := c.run(context.Background(), []string{"up", "--port", "3307", "--help"})
+	if code != exitOK {
+		t.Errorf("expected help to succeed, got %d", code)
+	}
+	for _, expects := range []string{"usage: trysql up [flags]", "-p, --port value", "(default 0)"} {
+		if !strings.Contains(stdout.String(), expects) {
+			t.Errorf("expected help to contain '%s', got %s", expects, stdout.String())
+		}
+	}
+}
+
+func TestUnexpectedArguments(t *testing.T) {
+	defer utils.HandelPanic(t)
+	c, _, stderr := testCLI()
+	code := c.run(context.Background(), []string{"down", "--name", "db", "now"})
+	if code != exitUsage || !strings.Contains(stderr.String(), "unexpected arguments 'now'") {
+		t.Errorf("expected stray arguments to be refused, got %d: %s", code, stderr.String())
+	}
+}
+
+func TestUpKeepsArguments(t *testing.T) {
+	defer utils.HandelPanic(t)
+	c, _, _ := testCLI()
+	args := []string{"--name", "db", "--runtime", "docker", "kept", "as", "given"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.up(ctx, args[:4], nil)
+	if strings.Join(args[4:], " ") != "kept as given" {
+		t.Errorf("expected up to leave the arguments after its flags alone, got %v", args)
+	}
+}
+
+func testCLI() (*cli, *bytes.Buffer, *bytes.Buffer) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	return &cli{
+		stdin:  &bytes.Buffer{},
+		stdout: stdout,
+		stderr: stderr,
+	}, stdout, stderr
+}
@@ -2,14 +2,23 @@ package configs
 
 import (
 	"fmt"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/blainemoser/TrySql/utils"
 )
 
+var containerName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+var identifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 type Configs struct {
 	inputs       map[string][]string
+	sources      map[string]string
 	MysqlVersion string
 	BufferSize   int
 }
@@ -19,71 +28,422 @@ func New(inputs []string) (*Configs, error) {
 	if err != nil {
 		return nil, err
 	}
+	sources := make(map[string]string)
+	for key := range parsed {
+		sources[key] = "flag"
+	}
 	return &Configs{
-		inputs: parsed,
+		inputs:  parsed,
+		sources: sources,
 	}, nil
 }
 
+// Apply parses command line flags over the current settings, later flags replacing earlier ones
+func (c *Configs) Apply(inputs []string) error {
+	parsed, err := setInputs(inputs)
+	if err != nil {
+		return err
+	}
+	for key, values := range parsed {
+		c.inputs[key] = values
+		c.sources[key] = "flag"
+	}
+	return nil
+}
+
+// Set sets the values of a flag, named as on the command line without its dashes
+func (c *Configs) Set(flag string, values ...string) error {
+	return c.set(flag, "option", values)
+}
+
+// IsSet reports whether a flag has been given, from any source
+func (c *Configs) IsSet(flag string) bool {
+	_, ok := c.value(expected()[flag])
+	return ok
+}
+
+func (c *Configs) set(name, source string, values []string) error {
+	flag, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("the %s argument does not exist", name)
+	}
+	c.inputs[flag.Key] = values
+	c.sources[flag.Key] = source
+	return nil
+}
+
+// Validate checks every setting that has been given, so that mistakes are reported before any
+// container is started rather than replaced by defaults
+func (c *Configs) Validate() error {
+	errs := []error{
+		c.validateInt("Port", "port", 0, 65535),
+		c.validateInt("BufferSize", "buffer-size", 1, 1<<20),
+		c.validateInt("Tail", "tail", 0, 1<<31-1),
+		c.validateOption("Runtime", "runtime", "docker", "podman", "auto"),
+		c.validateOption("Engine", "engine", "mysql", "mariadb", "postgres"),
+		c.validateOption("ImageFamily", "image-family", "mysql-server", "mysql"),
+		c.validateOption("Readiness", "readiness", "healthcheck", "ping", "tcp", "query"),
+		c.validateOption("PullPolicy", "pull", "always", "if-not-present", "never"),
+		c.validateOption("Reporter", "reporter", "auto", "spinner", "plain", "json", "none"),
+		c.validateDuration("PullTimeout", "pull-timeout"),
+		c.validateDuration("StartTimeout", "start-timeout"),
+		c.validateDuration("HealthTimeout", "health-timeout"),
+		c.validateDuration("TeardownTimeout", "teardown-timeout"),
+		c.validateDuration("SeedTimeout", "seed-timeout"),
+		c.validateDuration("TTL", "ttl"),
+		c.validateOption("Lifetime", "lifetime", "process", "detached"),
+		c.validateName(),
+		c.validateDatabases(),
+		c.validateSeed(),
+		c.validateVariables(),
+		c.validateResources(),
+		c.validateCredentials(),
+	}
+	return utils.GetErrors(errs)
+}
+
+// value returns a setting as given, and whether it was given at all
+func (c *Configs) value(key string) (string, bool) {
+	if c.inputs[key] == nil || len(c.inputs[key]) < 1 {
+		return "", false
+	}
+	return c.inputs[key][0], true
+}
+
+func (c *Configs) validateInt(key, flag string, min, max int) error {
+	value, ok := c.value(key)
+	if !ok {
+		return nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || number > max {
+		return fmt.Errorf("%s must be a number from %d to %d, got '%s'", flag, min, max, value)
+	}
+	return nil
+}
+
+func (c *Configs) validateOption(key, flag string, options ...string) error {
+	value, ok := c.value(key)
+	if !ok {
+		return nil
+	}
+	for _, option := range options {
+		if strings.ToLower(value) == option {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got '%s'", flag, strings.Join(options, ", "), value)
+}
+
+func (c *Configs) validateDuration(key, flag string) error {
+	value, ok := c.value(key)
+	if !ok {
+		return nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err == nil && seconds > 0 {
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err == nil && duration > 0 {
+		return nil
+	}
+	return fmt.Errorf("%s must be a positive duration such as 90s or 2m, or a number of seconds, got '%s'", flag, value)
+}
+
+// validateDatabases keeps names to those that need no quoting in any engine
+func (c *Configs) validateDatabases() error {
+	for _, database := range c.GetDatabases() {
+		if !identifier.MatchString(database) {
+			return fmt.Errorf("databases must be letters, digits and underscores, not starting with a digit, got '%s'", database)
+		}
+	}
+	return nil
+}
+
+func (c *Configs) validateSeed() error {
+	for _, script := range c.GetSeed() {
+		info, err := os.Stat(script)
+		if err != nil || info.IsDir() {
+			return fmt.Errorf("seed script '%s' is not a readable file", script)
+		}
+	}
+	return nil
+}
+
+func (c *Configs) validateVariables() error {
+	for _, variable := range c.inputs["Variables"] {
+		name, _, ok := strings.Cut(variable, "=")
+		if !ok || !identifier.MatchString(strings.ReplaceAll(name, "-", "_")) {
+			return fmt.Errorf("variables must be given as name=value, got '%s'", variable)
+		}
+	}
+	return nil
+}
+
+func (c *Configs) validateResources() error {
+	value, ok := c.value("Memory")
+	if ok {
+		_, err := parseBytes(value)
+		if err != nil {
+			return fmt.Errorf("memory must be a size such as 512m or 2g, got '%s'", value)
+		}
+	}
+	value, ok = c.value("CPUs")
+	if ok {
+		cpus, err := strconv.ParseFloat(value, 64)
+		if err != nil || cpus <= 0 {
+			return fmt.Errorf("cpus must be a positive number such as 1.5, got '%s'", value)
+		}
+	}
+	return nil
+}
+
+// validateCredentials checks the password policy and that at most one source of the password is given
+func (c *Configs) validateCredentials() error {
+	errs := []error{
+		c.validateInt("PasswordLength", "password-length", 12, 256),
+		c.validateOption("PasswordCharset", "password-charset", "letters", "alphanumeric", "symbols"),
+	}
+	password, hasPassword := c.value("Password")
+	file, hasFile := c.value("PasswordFile")
+	switch {
+	case hasPassword && hasFile:
+		errs = append(errs, fmt.Errorf("give either password or password-file, not both"))
+	case hasPassword && (len(password) < 1 || strings.Contains(password, "\n")):
+		errs = append(errs, fmt.Errorf("password may not be empty or span lines"))
+	case hasFile:
+		info, err := os.Stat(file)
+		if err != nil || info.IsDir() {
+			errs = append(errs, fmt.Errorf("password file '%s' is not a readable file", file))
+		}
+	}
+	user, ok := c.value("User")
+	if ok && (!identifier.MatchString(user) || user == "root" || user == "postgres") {
+		errs = append(errs, fmt.Errorf("user must be letters, digits and underscores, not starting with a digit nor a superuser, got '%s'", user))
+	}
+	return utils.GetErrors(errs)
+}
+
+// validateName allows what container runtimes allow in container names
+func (c *Configs) validateName() error {
+	value, ok := c.value("Name")
+	if !ok || containerName.MatchString(value) {
+		return nil
+	}
+	return fmt.Errorf("name must start with a letter or digit, followed by letters, digits, '_', '.' or '-', got '%s'", value)
+}
+
+// Flag is a setting as given on the command line. Its name is also its key in config files and,
+// upper-cased with underscores, the name of its TRYSQL_ environment variable
+type Flag struct {
+	Name    string
+	Aliases []string
+	Key     string
+	Default string
+	Usage   string
+	Multi   bool
+}
+
+var flagTable = []Flag{
+	{Name: "version", Aliases: []string{"v"}, Key: "MysqlVersion", Default: "latest", Usage: "version tag of the engine's image"},
+	{Name: "port", Aliases: []string{"p"}, Key: "Port", Default: "0", Usage: "host port the server is published on, 0 for a free one"},
+	{Name: "engine", Aliases: []string{"e"}, Key: "Engine", Default: "mysql", Usage: "database engine: mysql, mariadb or postgres"},
+	{Name: "runtime", Aliases: []string{"r"}, Key: "Runtime", Default: "auto", Usage: "container runtime: docker, podman or auto"},
+	{Name: "image", Aliases: []string{"i"}, Key: "Image", Usage: "custom image to run in place of the engine's own"},
+	{Name: "image-family", Key: "ImageFamily", Usage: "MySQL image family: mysql-server or mysql, inferred from a custom image unless given"},
+	{Name: "readiness", Key: "Readiness", Usage: "how to wait for the server: healthcheck, ping, tcp or query"},
+	{Name: "name", Aliases: []string{"n"}, Key: "Name", Usage: "container name, generated unless given"},
+	{Name: "databases", Aliases: []string{"database"}, Key: "Databases", Usage: "databases to create once the server is up", Multi: true},
+	{Name: "seed", Key: "Seed", Usage: "SQL scripts to run, in order, once the databases exist", Multi: true},
+	{Name: "variable", Key: "Variables", Usage: "server variables, each as name=value", Multi: true},
+	{Name: "password", Key: "Password", Usage: "superuser password, generated unless given"},
+	{Name: "password-file", Key: "PasswordFile", Usage: "file whose first line is the superuser password"},
+	{Name: "password-length", Key: "PasswordLength", Default: "32", Usage: "length of generated passwords"},
+	{Name: "password-charset", Key: "PasswordCharset", Default: "alphanumeric", Usage: "characters of generated passwords: letters, alphanumeric or symbols"},
+	{Name: "user", Key: "User", Usage: "application user to create, with a generated password and all privileges on the databases"},
+	{Name: "ttl", Key: "TTL", Usage: "how long the sandbox may live before prune removes it, e.g. 2h"},
+	{Name: "lifetime", Key: "Lifetime", Default: "process", Usage: "what the sandbox outlives: process, pruned once the process that started it exits, or detached"},
+	{Name: "memory", Key: "Memory", Usage: "memory limit of the container, e.g. 512m or 2g"},
+	{Name: "cpus", Key: "CPUs", Usage: "number of CPUs the container may use, e.g. 1.5"},
+	{Name: "pull", Key: "PullPolicy", Default: "if-not-present", Usage: "when to pull the image: always, if-not-present or never, to work offline"},
+	{Name: "pull-timeout", Key: "PullTimeout", Default: "3m0s", Usage: "longest the image pull may go without progress"},
+	{Name: "start-timeout", Key: "StartTimeout", Default: "3m0s", Usage: "longest creating and starting the container may take"},
+	{Name: "health-timeout", Key: "HealthTimeout", Default: "2m0s", Usage: "longest the server may take to become ready"},
+	{Name: "teardown-timeout", Key: "TeardownTimeout", Default: "1m0s", Usage: "longest stopping and removing may each take"},
+	{Name: "seed-timeout", Key: "SeedTimeout", Default: "5m0s", Usage: "longest creating the databases and running the seed scripts may take"},
+	{Name: "reporter", Key: "Reporter", Default: "auto", Usage: "progress output: auto, spinner, plain, json or none"},
+	{Name: "buffer-size", Aliases: []string{"bs"}, Key: "BufferSize", Default: "10", Usage: "buffer size"},
+	{Name: "tail", Key: "Tail", Default: "100", Usage: "lines of the server's log to show"},
+	{Name: "config", Key: "Config", Usage: "config file, found by walking up from the working directory unless given"},
+}
+
 func expected() map[string]string {
-	return map[string]string{
-		"v":           "MysqlVersion",
-		"version":     "MysqlVersion",
-		"bs":          "BufferSize",
-		"buffer-size": "BufferSize",
-		"port":        "Port",
-		"p":           "Port",
+	result := make(map[string]string)
+	for _, flag := range flagTable {
+		result[flag.Name] = flag.Key
+		for _, alias := range flag.Aliases {
+			result[alias] = flag.Key
+		}
+	}
+	return result
+}
+
+// Lookup returns the flag of the given name or alias
+func Lookup(name string) (Flag, bool) {
+	key := expected()[name]
+	for _, flag := range flagTable {
+		if flag.Key == key {
+			return flag, true
+		}
 	}
+	return Flag{}, false
 }
 
+// setInputs parses flags, each given as --flag value or --flag=value. Flags taking several values
+// take every value up to the next flag. A bare flag name is also taken as a flag, once the flag
+// before it has its value
 func setInputs(inputs []string) (map[string][]string, error) {
-	args := expected()
 	result := make(map[string][]string)
-	var err error
-	var curIndex string
-	for i := 0; i < len(inputs); i++ {
-		v := strings.Trim(inputs[i], " ")
-		if strings.Contains(v, "=") {
-			err = getSplitConfigs(v, args, &result, &curIndex)
-		} else {
-			err = appendConfig(&curIndex, args, &result, v)
+	var current Flag
+	for _, input := range inputs {
+		token := strings.TrimSpace(input)
+		if strings.HasPrefix(token, "-") {
+			name := token
+			removeDashes(&name)
+			name, value, hasValue := strings.Cut(name, "=")
+			flag, ok := Lookup(name)
+			if !ok {
+				// A negative number, say, is the value of a flag still waiting for one
+				if len(current.Key) > 0 && len(result[current.Key]) < 1 && !hasValue {
+					result[current.Key] = append(result[current.Key], token)
+					continue
+				}
+				return nil, unknownFlag(name)
+			}
+			current = startFlag(result, flag)
+			if hasValue {
+				result[current.Key] = append(result[current.Key], value)
+			}
+			continue
 		}
-		if err != nil {
-			return nil, err
+		flag, ok := Lookup(token)
+		if ok && (len(current.Key) < 1 || len(result[current.Key]) > 0) {
+			current = startFlag(result, flag)
+			continue
+		}
+		if len(current.Key) < 1 {
+			return nil, unknownFlag(token)
+		}
+		if !current.Multi && len(result[current.Key]) > 0 {
+			return nil, fmt.Errorf("%s takes a single value, got '%s' and '%s'", current.Name, result[current.Key][0], token)
+		}
+		result[current.Key] = append(result[current.Key], token)
+	}
+	for _, flag := range flagTable {
+		values, ok := result[flag.Key]
+		if ok && len(values) < 1 {
+			return nil, fmt.Errorf("%s needs a value", flag.Name)
 		}
 	}
 	return result, nil
 }
 
-func getSplitConfigs(v string, args map[string]string, result *map[string][]string, curIndex *string) error {
-	configs := strings.Split(v, "=")
-	var err error
-	var errs []error
-	for _, c := range configs {
-		c = strings.Trim(c, " ")
-		err = appendConfig(curIndex, args, result, c)
-		errs = append(errs, err)
+// startFlag makes way for a flag's values. Flags taking several values collect them across
+// repeats, others keep only the last
+func startFlag(result map[string][]string, flag Flag) Flag {
+	if !flag.Multi || result[flag.Key] == nil {
+		result[flag.Key] = make([]string, 0)
 	}
-	return utils.GetErrors(errs)
+	return flag
 }
 
-func appendConfig(curIndex *string, args map[string]string, result *map[string][]string, arg string) error {
-	removeDashes(&arg)
-	if args[arg] == "" {
-		if (*result)[*curIndex] == nil {
-			err := fmt.Errorf("the %s argument does not exist", arg)
-			return err
+// unknownFlag suggests the closest flag, if any is close enough to be a typo
+func unknownFlag(name string) error {
+	best := ""
+	bestDistance := len(name)/2 + 1
+	if bestDistance > 3 {
+		bestDistance = 3
+	}
+	for _, flag := range flagTable {
+		for _, candidate := range append([]string{flag.Name}, flag.Aliases...) {
+			distance := editDistance(name, candidate)
+			if distance < bestDistance {
+				best = flag.Name
+				bestDistance = distance
+			}
 		}
-		(*result)[*curIndex] = append((*result)[*curIndex], arg)
-	} else {
-		*curIndex = args[arg]
-		(*result)[*curIndex] = make([]string, 0)
 	}
-	return nil
+	if len(best) > 0 {
+		return fmt.Errorf("the %s argument does not exist, did you mean --%s?", name, best)
+	}
+	return fmt.Errorf("the %s argument does not exist", name)
 }
 
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+// Usage describes every flag, for --help
+func Usage() string {
+	out := &strings.Builder{}
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	for _, flag := range flagTable {
+		names := make([]string, 0, len(flag.Aliases)+1)
+		for _, alias := range flag.Aliases {
+			if len(alias) < 3 {
+				names = append(names, "-"+alias)
+				continue
+			}
+			names = append(names, "--"+alias)
+		}
+		names = append(names, "--"+flag.Name)
+		value := "value"
+		if flag.Multi {
+			value = "value..."
+		}
+		usage := flag.Usage
+		if len(flag.Default) > 0 {
+			usage += " (default " + flag.Default + ")"
+		}
+		fmt.Fprintf(writer, "  %s %s\t%s\n", strings.Join(names, ", "), value, usage)
+	}
+	writer.Flush()
+	fmt.Fprintf(out, "\nFlags can also be set in a .trysql.yaml or .trysql.json file, or as %s<FLAG> environment variables\n", envPrefix)
+	return out.String()
+}
+
+// removeDashes strips the leading dashes of a flag, leaving dashes within values such as image names intact
 func removeDashes(input *string) {
-	result := strings.Replace(*input, "-", "", 2)
-	*input = result
+	result := strings.TrimPrefix(*input, "-")
+	*input = strings.TrimPrefix(result, "-")
 }
 
 func (c *Configs) GetMysqlVersion() string {
@@ -97,11 +457,11 @@ func (c *Configs) GetPort() int {
 	if c.inputs["Port"] != nil && len(c.inputs["Port"]) > 0 {
 		port, err := strconv.Atoi(c.inputs["Port"][0])
 		if err != nil {
-			return 6603
+			return 0
 		}
 		return port
 	}
-	return 6603
+	return 0
 }
 
 func (c *Configs) GetBufferSize() int {
@@ -115,3 +475,250 @@ func (c *Configs) GetBufferSize() int {
 	}
 	return 10
 }
+
+// GetRuntime returns the requested container runtime: docker, podman or auto
+func (c *Configs) GetRuntime() string {
+	if c.inputs["Runtime"] != nil && len(c.inputs["Runtime"]) > 0 {
+		return c.inputs["Runtime"][0]
+	}
+	return "auto"
+}
+
+// GetReporter returns how progress is reported: auto, spinner, plain, json or none
+func (c *Configs) GetReporter() string {
+	if c.inputs["Reporter"] != nil && len(c.inputs["Reporter"]) > 0 {
+		return c.inputs["Reporter"][0]
+	}
+	return "auto"
+}
+
+// GetName returns the sandbox's container name, empty when a unique one should be generated
+func (c *Configs) GetName() string {
+	if c.inputs["Name"] != nil && len(c.inputs["Name"]) > 0 {
+		return c.inputs["Name"][0]
+	}
+	return ""
+}
+
+// GetTail returns how many lines of the server's log to show, 100 unless told otherwise
+func (c *Configs) GetTail() int {
+	if c.inputs["Tail"] != nil && len(c.inputs["Tail"]) > 0 {
+		tail, err := strconv.Atoi(c.inputs["Tail"][0])
+		if err != nil {
+			return 100
+		}
+		return tail
+	}
+	return 100
+}
+
+// GetEngine returns the requested database engine, mysql unless another is given
+func (c *Configs) GetEngine() string {
+	if c.inputs["Engine"] != nil && len(c.inputs["Engine"]) > 0 {
+		return c.inputs["Engine"][0]
+	}
+	return "mysql"
+}
+
+// GetImage returns a custom image reference, which replaces the engine's own image when set
+func (c *Configs) GetImage() string {
+	if c.inputs["Image"] != nil && len(c.inputs["Image"]) > 0 {
+		return c.inputs["Image"][0]
+	}
+	return ""
+}
+
+// GetImageFamily returns the requested image family for the mysql engine, empty for its default
+func (c *Configs) GetImageFamily() string {
+	if c.inputs["ImageFamily"] != nil && len(c.inputs["ImageFamily"]) > 0 {
+		return c.inputs["ImageFamily"][0]
+	}
+	return ""
+}
+
+// GetReadiness returns the requested readiness strategy, empty for the engine's default
+func (c *Configs) GetReadiness() string {
+	if c.inputs["Readiness"] != nil && len(c.inputs["Readiness"]) > 0 {
+		return c.inputs["Readiness"][0]
+	}
+	return ""
+}
+
+// GetPullPolicy returns when the image is pulled: always, if-not-present or never
+func (c *Configs) GetPullPolicy() string {
+	if c.inputs["PullPolicy"] != nil && len(c.inputs["PullPolicy"]) > 0 {
+		return strings.ToLower(c.inputs["PullPolicy"][0])
+	}
+	return "if-not-present"
+}
+
+// GetPullTimeout returns how long pulling the image may go without progress
+func (c *Configs) GetPullTimeout() time.Duration {
+	return c.getDuration("PullTimeout", 3*time.Minute)
+}
+
+// GetStartTimeout returns how long creating and starting the container may take
+func (c *Configs) GetStartTimeout() time.Duration {
+	return c.getDuration("StartTimeout", 3*time.Minute)
+}
+
+// GetHealthTimeout returns how long the started server may take to become ready
+func (c *Configs) GetHealthTimeout() time.Duration {
+	return c.getDuration("HealthTimeout", 2*time.Minute)
+}
+
+// GetTeardownTimeout returns how long stopping and removing the container may each take
+func (c *Configs) GetTeardownTimeout() time.Duration {
+	return c.getDuration("TeardownTimeout", time.Minute)
+}
+
+// GetSeedTimeout returns how long creating the databases and running the seed scripts may take
+func (c *Configs) GetSeedTimeout() time.Duration {
+	return c.getDuration("SeedTimeout", 5*time.Minute)
+}
+
+// GetTTL returns how long the sandbox may live before it is pruned, zero when it may live forever
+func (c *Configs) GetTTL() time.Duration {
+	return c.getDuration("TTL", 0)
+}
+
+// GetLifetime returns what the sandbox outlives: process, the default, or detached
+func (c *Configs) GetLifetime() string {
+	if c.inputs["Lifetime"] != nil && len(c.inputs["Lifetime"]) > 0 {
+		return strings.ToLower(c.inputs["Lifetime"][0])
+	}
+	return "process"
+}
+
+// GetDatabases returns the databases to create once the server is up
+func (c *Configs) GetDatabases() []string {
+	return c.inputs["Databases"]
+}
+
+// GetSeed returns the SQL scripts to run, in order, once the databases exist
+func (c *Configs) GetSeed() []string {
+	return c.inputs["Seed"]
+}
+
+// GetPassword returns the superuser password given, empty when it is to be read from a file or generated
+func (c *Configs) GetPassword() string {
+	password, _ := c.value("Password")
+	return password
+}
+
+// GetPasswordFile returns the path of the file holding the superuser password
+func (c *Configs) GetPasswordFile() string {
+	path, _ := c.value("PasswordFile")
+	return path
+}
+
+// GetPasswordLength returns the length of generated passwords, 32 unless told otherwise
+func (c *Configs) GetPasswordLength() int {
+	value, _ := c.value("PasswordLength")
+	length, err := strconv.Atoi(value)
+	if err != nil {
+		return 32
+	}
+	return length
+}
+
+// GetPasswordCharset returns which characters generated passwords are made of, alphanumeric unless told otherwise
+func (c *Configs) GetPasswordCharset() string {
+	charset, ok := c.value("PasswordCharset")
+	if !ok {
+		return "alphanumeric"
+	}
+	return strings.ToLower(charset)
+}
+
+// GetUser returns the name of the application user to create, if any
+func (c *Configs) GetUser() string {
+	user, _ := c.value("User")
+	return user
+}
+
+// GetVariables returns the server variables to start the server with
+func (c *Configs) GetVariables() map[string]string {
+	variables := make(map[string]string)
+	for _, variable := range c.inputs["Variables"] {
+		name, value, ok := strings.Cut(variable, "=")
+		if ok {
+			variables[name] = value
+		}
+	}
+	return variables
+}
+
+// GetMemory returns the container's memory limit in bytes, zero for no limit
+func (c *Configs) GetMemory() int64 {
+	value, ok := c.value("Memory")
+	if !ok {
+		return 0
+	}
+	bytes, err := parseBytes(value)
+	if err != nil {
+		return 0
+	}
+	return bytes
+}
+
+// GetCPUs returns how many CPUs the container may use, zero for no limit
+func (c *Configs) GetCPUs() float64 {
+	value, ok := c.value("CPUs")
+	if !ok {
+		return 0
+	}
+	cpus, err := strconv.ParseFloat(value, 64)
+	if err != nil || cpus < 0 {
+		return 0
+	}
+	return cpus
+}
+
+// GetConfig returns the config file the settings were loaded from, if any
+func (c *Configs) GetConfig() string {
+	value, _ := c.value("Config")
+	return value
+}
+
+// parseBytes reads a size such as 512m, 2g or a plain number of bytes
+func parseBytes(value string) (int64, error) {
+	units := map[string]int64{
+		"b": 1,
+		"k": 1 << 10,
+		"m": 1 << 20,
+		"g": 1 << 30,
+	}
+	value = strings.ToLower(strings.TrimSpace(value))
+	value = strings.TrimSuffix(value, "b")
+	multiplier := int64(1)
+	if len(value) > 0 {
+		unit, ok := units[value[len(value)-1:]]
+		if ok {
+			multiplier = unit
+			value = value[:len(value)-1]
+		}
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// getDuration reads a duration such as 90s or 2m, or a plain number of seconds
+func (c *Configs) getDuration(key string, fallback time.Duration) time.Duration {
+	if c.inputs[key] == nil || len(c.inputs[key]) < 1 {
+		return fallback
+	}
+	value := c.inputs[key][0]
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+	return duration
+}
@@ -2,7 +2,9 @@ package configs
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/blainemoser/TrySql/utils"
 )
@@ -25,7 +27,7 @@ func TestEqualsSetting(t *testing.T) {
 
 func TestNonConfig(t *testing.T) {
 	_, err := New([]string{"--tersion", "latest", "--buffer-size", "100"})
-	exp := "the tersion argument does not exist"
+	exp := "the tersion argument does not exist, did you mean --version?"
 	if err == nil {
 		t.Error(fmt.Errorf("expected error: %s", exp))
 		return
@@ -35,6 +37,140 @@ func TestNonConfig(t *testing.T) {
 	}
 }
 
+func TestRuntime(t *testing.T) {
+	configs, err := New([]string{"--runtime=podman"})
+	if err != nil {
+		t.Error(err)
+	}
+	if configs.GetRuntime() != "podman" {
+		t.Errorf("expected 'runtime' to be 'podman', got '%s'", configs.GetRuntime())
+	}
+	configs, _ = New([]string{})
+	if configs.GetRuntime() != "auto" {
+		t.Errorf("expected 'runtime' to default to 'auto', got '%s'", configs.GetRuntime())
+	}
+	if configs.GetPullPolicy() != "if-not-present" {
+		t.Errorf("expected 'pull' to default to 'if-not-present', got '%s'", configs.GetPullPolicy())
+	}
+	if configs.GetPort() != 0 {
+		t.Errorf("expected 'port' to default to 0 for a free one, got %d", configs.GetPort())
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	configs, err := New([]string{"--pull-timeout", "10m", "--health-timeout=90", "--start-timeout", "soon"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := map[string][2]time.Duration{
+		"pull-timeout":     {configs.GetPullTimeout(), 10 * time.Minute},
+		"health-timeout":   {configs.GetHealthTimeout(), 90 * time.Second},
+		"start-timeout":    {configs.GetStartTimeout(), 3 * time.Minute},
+		"teardown-timeout": {configs.GetTeardownTimeout(), time.Minute},
+	}
+	for flag, durations := range expects {
+		if durations[0] != durations[1] {
+			t.Errorf("expected '%s' to be %s, got %s", flag, durations[1], durations[0])
+		}
+	}
+}
+
+func TestSetAndApply(t *testing.T) {
+	configs, err := New([]string{"--engine", "postgres", "--port", "5433"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = configs.Set("version", "16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = configs.Apply([]string{"--port=5434"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs.GetMysqlVersion() != "16" || configs.GetPort() != 5434 || configs.GetEngine() != "postgres" {
+		t.Errorf("expected postgres 16 on 5434, got %s %s on %d", configs.GetEngine(), configs.GetMysqlVersion(), configs.GetPort())
+	}
+	err = configs.Set("colour", "blue")
+	if err == nil {
+		t.Errorf("expected an unknown flag to be refused")
+	}
+}
+
+func TestStrictParsing(t *testing.T) {
+	configs, err := New([]string{"--variable=sql_mode=ANSI,TRADITIONAL", "--name=port", "--image", "registry.local/mysql:8.0-debug", "--health-timeout", "-5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs.GetVariables()["sql_mode"] != "ANSI,TRADITIONAL" || configs.GetName() != "port" {
+		t.Errorf("expected values to be kept whole, got %v and '%s'", configs.GetVariables(), configs.GetName())
+	}
+	if configs.GetImage() != "registry.local/mysql:8.0-debug" {
+		t.Errorf("expected dashes within the image to be kept, got '%s'", configs.GetImage())
+	}
+	err = configs.Validate()
+	if err == nil || !strings.Contains(err.Error(), "health-timeout must be a positive duration") {
+		t.Errorf("expected the negative timeout to fail validation, got %v", err)
+	}
+	invalid := map[string][]string{
+		"port needs a value": {"--version", "8.0", "--port"},
+		"port takes a single value, got '3307' and '3308'":               {"--port", "3307", "3308"},
+		"the databse argument does not exist, did you mean --databases?": {"--databse", "app"},
+		"the latest argument does not exist":                             {"latest"},
+	}
+	for expects, args := range invalid {
+		_, err = New(args)
+		if err == nil || err.Error() != expects {
+			t.Errorf("expected '%s' for %v, got %v", expects, args, err)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	usage := Usage()
+	for _, expects := range []string{"-v, --version value", "(default latest)", "--database, --databases value...", "TRYSQL_<FLAG>"} {
+		if !strings.Contains(usage, expects) {
+			t.Errorf("expected usage to contain '%s', got\n%s", expects, usage)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{"--port", "0", "--engine", "MariaDB", "--reporter", "json", "--pull-timeout", "90", "--pull", "Never", "--name", "my_db.1"}
+	configs, err := New(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = configs.Validate()
+	if err != nil {
+		t.Errorf("expected %v to be valid, got %s", valid, err.Error())
+	}
+	invalid := map[string][]string{
+		"port must be a number from 0 to 65535, got '65536'":        {"--port", "65536"},
+		"buffer-size must be a number from 1 to 1048576, got 'big'": {"--buffer-size", "big"},
+		"runtime must be one of docker, podman, auto, got 'lxc'":    {"--runtime", "lxc"},
+		"pull must be one of always, if-not-present, never":         {"--pull", "missing"},
+		"ttl must be a positive duration":                           {"--ttl", "-1h"},
+		"lifetime must be one of process, detached":                 {"--lifetime", "forever"},
+		"health-timeout must be a positive duration":                {"--health-timeout", "0"},
+		"name must start with a letter or digit":                    {"--name", "my db"},
+		"password-length must be a number from 12 to 256":           {"--password-length", "8"},
+		"password-charset must be one of letters, alphanumeric":     {"--password-charset", "emoji"},
+		"give either password or password-file, not both":           {"--password", "secret", "--password-file", "pw.txt"},
+		"user must be letters, digits and underscores":              {"--user", "root"},
+	}
+	for expects, args := range invalid {
+		configs, err = New(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = configs.Validate()
+		if err == nil || !strings.Contains(err.Error(), expects) {
+			t.Errorf("expected '%s' for %v, got %v", expects, args, err)
+		}
+	}
+}
+
 func check(configs *Configs, t *testing.T) {
 	var errs []error
 	version := configs.GetMysqlVersion()
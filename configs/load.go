@@ -0,0 +1,261 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blainemoser/TrySql/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// The config file names looked for, in order, in each directory from the working one upwards
+var configFiles = []string{".trysql.yaml", ".trysql.yml", ".trysql.json"}
+
+// envPrefix starts the environment variable of every flag, e.g. TRYSQL_PULL_TIMEOUT
+const envPrefix = "TRYSQL_"
+
+// Load layers the settings of the config file, then the TRYSQL_ environment variables and then
+// the args, each overriding the ones before. The file is the one named by the config flag or
+// variable, otherwise the first found by walking up from the working directory
+func Load(args []string) (*Configs, error) {
+	flags, err := New(args)
+	if err != nil {
+		return nil, err
+	}
+	c := &Configs{
+		inputs:  make(map[string][]string),
+		sources: make(map[string]string),
+	}
+	path, err := configPath(flags)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) > 0 {
+		err = c.loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		c.sources["Config"] = "discovered"
+	}
+	c.loadEnv()
+	for key, values := range flags.inputs {
+		c.inputs[key] = values
+		c.sources[key] = "flag"
+	}
+	if len(path) > 0 {
+		c.inputs["Config"] = []string{path}
+	}
+	return c, nil
+}
+
+func configPath(flags *Configs) (string, error) {
+	path := flags.GetConfig()
+	if len(path) < 1 {
+		path = os.Getenv(envPrefix + "CONFIG")
+	}
+	if len(path) > 0 {
+		return filepath.Abs(path)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return discover(dir), nil
+}
+
+// discover returns the first config file found in dir or its parents, or nothing
+func discover(dir string) string {
+	for {
+		for _, name := range configFiles {
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadFile reads a YAML or JSON file whose keys are the long flag names. Besides those it takes
+// variables as a map of server variables and resources as a map of memory and cpus. Seed
+// scripts and the password file are relative to the file's directory
+func (c *Configs) loadFile(path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	settings, err := parseFile(path, contents)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	source := "file " + path
+	for name, setting := range settings {
+		switch name {
+		case "variables":
+			err = c.fileVariables(setting, source)
+		case "resources":
+			err = c.fileResources(setting, source)
+		default:
+			err = c.fileSetting(name, setting, source)
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+	for _, key := range []string{"Seed", "PasswordFile"} {
+		files := c.inputs[key]
+		for i, file := range files {
+			if !filepath.IsAbs(file) {
+				files[i] = filepath.Join(filepath.Dir(path), file)
+			}
+		}
+	}
+	return nil
+}
+
+// parseFile keeps values as they are written, so that a version of 8.0 is not read as the number 8
+func parseFile(path string, contents []byte) (map[string]interface{}, error) {
+	settings := make(map[string]interface{})
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(contents))
+		decoder.UseNumber()
+		err := decoder.Decode(&settings)
+		return settings, err
+	}
+	document := &yaml.Node{}
+	err := yaml.Unmarshal(contents, document)
+	if err != nil || len(document.Content) < 1 {
+		return settings, err
+	}
+	mapping, ok := yamlValue(document.Content[0]).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping of settings")
+	}
+	return mapping, nil
+}
+
+func yamlValue(node *yaml.Node) interface{} {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		list := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			list[i] = yamlValue(item)
+		}
+		return list
+	case yaml.MappingNode:
+		mapping := make(map[string]interface{})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			mapping[node.Content[i].Value] = yamlValue(node.Content[i+1])
+		}
+		return mapping
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	}
+	return node.Value
+}
+
+func (c *Configs) fileSetting(name string, setting interface{}, source string) error {
+	flag, ok := Lookup(name)
+	if !ok || flag.Name != name {
+		return fmt.Errorf("unknown setting '%s'", name)
+	}
+	list, isList := setting.([]interface{})
+	if !isList {
+		return c.set(name, source, []string{fmt.Sprint(setting)})
+	}
+	if !flag.Multi {
+		return fmt.Errorf("%s takes a single value, not a list", name)
+	}
+	values := make([]string, len(list))
+	for i, value := range list {
+		values[i] = fmt.Sprint(value)
+	}
+	return c.set(name, source, values)
+}
+
+func (c *Configs) fileVariables(setting interface{}, source string) error {
+	variables, ok := setting.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("variables must map names to values")
+	}
+	values := make([]string, 0, len(variables))
+	for name, value := range variables {
+		values = append(values, name+"="+fmt.Sprint(value))
+	}
+	sort.Strings(values)
+	return c.set("variable", source, values)
+}
+
+func (c *Configs) fileResources(setting interface{}, source string) error {
+	resources, ok := setting.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("resources must map memory and cpus to their limits")
+	}
+	for name, value := range resources {
+		if name != "memory" && name != "cpus" {
+			return fmt.Errorf("unknown resource '%s', expected memory or cpus", name)
+		}
+		err := c.set(name, source, []string{fmt.Sprint(value)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadEnv reads the TRYSQL_ variable of each flag. Flags taking several values take them comma separated
+func (c *Configs) loadEnv() {
+	for _, flag := range flagTable {
+		name := EnvName(flag.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok || len(value) < 1 {
+			continue
+		}
+		values := []string{value}
+		if flag.Multi {
+			values = strings.Split(value, ",")
+		}
+		c.inputs[flag.Key] = values
+		c.sources[flag.Key] = "env " + name
+	}
+}
+
+// EnvName returns the environment variable of a flag, e.g. TRYSQL_PULL_TIMEOUT for pull-timeout
+func EnvName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// String lists the effective settings with where each came from: a flag, an option, the
+// environment, a file or the defaults. A given password is masked
+func (c *Configs) String() string {
+	out := &strings.Builder{}
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, flag := range flagTable {
+		value := strings.Join(c.inputs[flag.Key], ",")
+		source := c.sources[flag.Key]
+		if _, ok := c.value(flag.Key); !ok {
+			value = flag.Default
+			source = "default"
+		}
+		if len(value) < 1 {
+			value = "-"
+		}
+		if flag.Key == "Password" && value != "-" {
+			value = utils.Redacted
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", flag.Name, value, source)
+	}
+	writer.Flush()
+	return out.String()
+}
@@ -0,0 +1,123 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".trysql.yaml"), `
+version: 8.0
+port: 3307
+engine: mariadb
+databases: [app, app_test]
+seed:
+  - sql/schema.sql
+variables:
+  max_connections: 500
+resources:
+  memory: 1g
+  cpus: 1.5
+`)
+	nested := filepath.Join(dir, "service", "internal")
+	err := os.MkdirAll(nested, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, nested)
+	t.Setenv("TRYSQL_PORT", "3308")
+	t.Setenv("TRYSQL_ENGINE", "postgres")
+	configs, err := Load([]string{"--engine", "mysql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs.GetMysqlVersion() != "8.0" {
+		t.Errorf("expected the file's version 8.0 to be kept as written, got '%s'", configs.GetMysqlVersion())
+	}
+	if configs.GetPort() != 3308 || configs.GetEngine() != "mysql" {
+		t.Errorf("expected the environment's port and the flag's engine, got %d and %s", configs.GetPort(), configs.GetEngine())
+	}
+	if strings.Join(configs.GetDatabases(), ",") != "app,app_test" {
+		t.Errorf("expected both databases, got %v", configs.GetDatabases())
+	}
+	expects := filepath.Join(dir, "sql", "schema.sql")
+	if len(configs.GetSeed()) != 1 || configs.GetSeed()[0] != expects {
+		t.Errorf("expected the seed script relative to the file, got %v", configs.GetSeed())
+	}
+	if configs.GetVariables()["max_connections"] != "500" {
+		t.Errorf("expected max_connections to be 500, got %v", configs.GetVariables())
+	}
+	if configs.GetMemory() != 1<<30 || configs.GetCPUs() != 1.5 {
+		t.Errorf("expected 1g and 1.5 cpus, got %d and %f", configs.GetMemory(), configs.GetCPUs())
+	}
+	printed := configs.String()
+	for _, line := range []string{"port              3308", "env TRYSQL_PORT", "file " + filepath.Join(dir, ".trysql.yaml"), "runtime           auto"} {
+		if !strings.Contains(printed, line) {
+			t.Errorf("expected the printed config to contain '%s', got\n%s", line, printed)
+		}
+	}
+}
+
+func TestStringMasksPassword(t *testing.T) {
+	t.Setenv("TRYSQL_PASSWORD", "s3cret")
+	configs, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	printed := configs.String()
+	if strings.Contains(printed, "s3cret") || !regexp.MustCompile(`(?m)^password +\*{8} +env TRYSQL_PASSWORD$`).MatchString(printed) {
+		t.Errorf("expected the password to be masked, got\n%s", printed)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sandbox.json")
+	writeFile(t, path, `{"version": 16, "engine": "postgres", "seed": ["/abs/seed.sql"]}`)
+	chdir(t, dir)
+	configs, err := Load([]string{"--config", "sandbox.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs.GetMysqlVersion() != "16" || configs.GetEngine() != "postgres" || configs.GetSeed()[0] != "/abs/seed.sql" {
+		t.Errorf("expected postgres 16 seeded from /abs/seed.sql, got %s %s %v", configs.GetEngine(), configs.GetMysqlVersion(), configs.GetSeed())
+	}
+	if configs.GetConfig() != path {
+		t.Errorf("expected the config file to be %s, got %s", path, configs.GetConfig())
+	}
+}
+
+func TestLoadRejectsUnknownSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".trysql.yml"), "colour: blue\n")
+	chdir(t, dir)
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown setting 'colour'") {
+		t.Errorf("expected an unknown setting to be refused, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	err := os.WriteFile(path, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func chdir(t *testing.T, dir string) {
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(previous)
+	})
+}
@@ -0,0 +1,121 @@
+package trysql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/engines"
+	"github.com/blainemoser/TrySql/utils"
+)
+
+// The label naming the application user and the file holding its password, so that attaching
+// recovers them
+const (
+	labelUser        = "trysql.user"
+	userPasswordFile = engines.SecretsDir + "/user-password"
+)
+
+// credentials are the superuser password, given or read from a file or otherwise generated to the
+// configured policy, and the generated password of the application user when there is one
+func credentials(confs *configs.Configs) (string, string, error) {
+	password, err := rootPassword(confs)
+	if err != nil {
+		return "", "", err
+	}
+	if len(confs.GetUser()) < 1 {
+		return password, "", nil
+	}
+	userPassword, err := generatePassword(confs)
+	if err != nil {
+		return "", "", err
+	}
+	return password, userPassword, nil
+}
+
+func rootPassword(confs *configs.Configs) (string, error) {
+	if password := confs.GetPassword(); len(password) > 0 {
+		return password, nil
+	}
+	path := confs.GetPasswordFile()
+	if len(path) < 1 {
+		return generatePassword(confs)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	password, _, _ := strings.Cut(string(contents), "\n")
+	password = strings.TrimSpace(password)
+	if len(password) < 1 {
+		return "", fmt.Errorf("password file '%s' is empty", path)
+	}
+	return password, nil
+}
+
+func generatePassword(confs *configs.Configs) (string, error) {
+	return utils.GeneratePassword(confs.GetPasswordLength(), utils.Charsets[confs.GetPasswordCharset()])
+}
+
+// AppUser returns the name of the application user, empty unless the user flag was given
+func (ts *TrySql) AppUser() string {
+	return ts.user
+}
+
+// AppPassword returns the generated password of the application user
+func (ts *TrySql) AppPassword() string {
+	return ts.userPassword
+}
+
+// createUser logs the application user in with all privileges on the configured databases
+func (ts *TrySql) createUser(ctx context.Context) error {
+	if len(ts.user) < 1 {
+		return nil
+	}
+	_, err := ts.RunScript(ctx, strings.NewReader(ts.engine.CreateUser(ts.user, ts.userPassword, ts.Configs.GetDatabases())))
+	if err != nil {
+		return fmt.Errorf("creating user %s: %w", ts.user, err)
+	}
+	return nil
+}
+
+// credentialFiles are written into the container before it starts. They are readable by every
+// user in the container, since the images' entrypoints read the password after dropping root
+func (ts *TrySql) credentialFiles() []docker.File {
+	contents := ts.engine.Files(ts.Password())
+	if len(ts.user) > 0 {
+		contents[userPasswordFile] = ts.userPassword
+	}
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	files := make([]docker.File, len(paths))
+	for i, path := range paths {
+		files[i] = docker.File{Path: path, Mode: 0444, Content: []byte(contents[path])}
+	}
+	return files
+}
+
+// readSecret reads one of the credential files back out of the container
+func (ts *TrySql) readSecret(ctx context.Context, path string) (string, error) {
+	result, err := ts.execInContainer(ctx, []string{"cat", path}, nil)
+	if err != nil {
+		return "", fmt.Errorf("reading %s from the sandbox: %w", path, err)
+	}
+	return result, nil
+}
+
+// secrets are the passwords to keep out of every error and log line
+func (ts *TrySql) secrets() []string {
+	return []string{ts.password, ts.userPassword}
+}
+
+func (ts *TrySql) redact(err error) error {
+	return utils.RedactError(err, ts.secrets()...)
+}
@@ -0,0 +1,94 @@
+package trysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/engines"
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestCredentials(t *testing.T) {
+	defer utils.HandelPanic(t)
+	password, userPassword, err := credentials(testConfigs(t, "--password-length", "48", "--password-charset", "letters"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(password) != 48 || strings.Trim(password, utils.Letters) != "" || len(userPassword) > 0 {
+		t.Errorf("expected a generated password of 48 letters and no user, got '%s' and '%s'", password, userPassword)
+	}
+	password, userPassword, _ = credentials(testConfigs(t, "--password", "given", "--user", "app"))
+	if password != "given" || len(userPassword) != 32 {
+		t.Errorf("expected the given password and a generated one for the user, got '%s' and '%s'", password, userPassword)
+	}
+	path := filepath.Join(t.TempDir(), "password")
+	err = os.WriteFile(path, []byte("from-file\nignored\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, _, _ = credentials(testConfigs(t, "--password-file", path))
+	if password != "from-file" {
+		t.Errorf("expected the first line of the password file, got '%s'", password)
+	}
+}
+
+func TestCredentialFiles(t *testing.T) {
+	defer utils.HandelPanic(t)
+	cnfs := testConfigs(t, "--engine", "mariadb", "--user", "app")
+	engine, err := engines.New(cnfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := &TrySql{engine: engine, password: "r00t", user: "app", userPassword: "s3cret", Configs: cnfs}
+	config := ts.getContainerConfig()
+	files := make(map[string]string)
+	for _, file := range config.Files {
+		files[file.Path] = string(file.Content)
+	}
+	if files[engines.PasswordFile] != "r00t" || files[userPasswordFile] != "s3cret" || len(files[engines.OptionFile]) < 1 {
+		t.Errorf("expected the password, option and user password files, got %v", files)
+	}
+	if strings.Contains(strings.Join(config.Env, " "), "r00t") || config.Labels[labelUser] != "app" {
+		t.Errorf("expected the password out of the environment and the user labelled, got %v and %v", config.Env, config.Labels)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	defer utils.HandelPanic(t)
+	ts := &TrySql{password: "r00tpass", userPassword: "s3cret"}
+	cause := &exitError{code: 1, stderr: "ERROR 1045: IDENTIFIED BY 's3cret'"}
+	err := ts.redact(fmt.Errorf("creating user app: %w", cause))
+	if err.Error() != "creating user app: exit status 1: ERROR 1045: IDENTIFIED BY '********'" {
+		t.Errorf("expected the user password to be redacted, got '%s'", err.Error())
+	}
+	exit := &exitError{}
+	if !errors.As(err, &exit) || strings.Contains(exit.stderr, "s3cret") {
+		t.Errorf("expected the redacted error to keep its cause, redacted")
+	}
+	err = ts.redact(fmt.Errorf("querying: %w", &QueryError{Number: 1045, Message: "denied for 'app' with 's3cret'", err: fmt.Errorf("driver: s3cret")}))
+	query := &QueryError{}
+	if !errors.As(err, &query) || strings.Contains(query.Message, "s3cret") || strings.Contains(query.Unwrap().Error(), "s3cret") {
+		t.Errorf("expected errors.As to find the query error redacted, got %v", query)
+	}
+	err = ts.redact(fmt.Errorf("starting: %w", &StartupError{Status: "exited", Logs: "password r00tpass"}))
+	startup := &StartupError{}
+	if !errors.As(err, &startup) || strings.Contains(startup.Logs, "r00tpass") {
+		t.Errorf("expected errors.As to find the startup logs redacted, got '%s'", startup.Logs)
+	}
+	err = ts.redact(fmt.Errorf("creating: %w", &docker.APIError{StatusCode: 400, Message: "invalid env MYSQL_PASSWORD=s3cret"}))
+	api := &docker.APIError{}
+	if !errors.As(err, &api) || api.StatusCode != 400 || strings.Contains(api.Message, "s3cret") {
+		t.Errorf("expected errors.As to find the engine's message redacted, got '%s'", api.Message)
+	}
+	ts.runtime = &fileRuntime{files: map[string]string{}}
+	_, err = ts.execInContainer(context.Background(), []string{"cat", "/r00tpass"}, nil)
+	if err == nil || strings.Contains(err.Error(), "r00tpass") {
+		t.Errorf("expected the client's stderr to be redacted, got %v", err)
+	}
+}
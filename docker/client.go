@@ -0,0 +1,502 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The engine API version requested on every call
+const apiVersion = "v1.41"
+
+const defaultHost = "unix:///var/run/docker.sock"
+
+// Client talks to the Docker Engine API over HTTP, on a unix socket or a tcp address
+type Client struct {
+	network string
+	address string
+	base    string
+	http    *http.Client
+}
+
+// DefaultHost returns DOCKER_HOST when it is set and the default engine socket otherwise
+func DefaultHost() string {
+	host := os.Getenv("DOCKER_HOST")
+	if len(host) > 0 {
+		return host
+	}
+	return defaultHost
+}
+
+// NewClient creates a client for a host of the form unix:///path/to/socket or tcp://host:port
+func NewClient(host string) (*Client, error) {
+	if len(host) < 1 {
+		host = DefaultHost()
+	}
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host '%s': %s", host, err.Error())
+	}
+	c := &Client{}
+	switch parsed.Scheme {
+	case "unix":
+		c.network = "unix"
+		c.address = parsed.Path
+		c.base = "http://docker"
+	case "tcp", "http":
+		c.network = "tcp"
+		c.address = parsed.Host
+		c.base = "http://" + parsed.Host
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme '%s'", parsed.Scheme)
+	}
+	c.http = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return c.dial(ctx)
+			},
+		},
+	}
+	return c, nil
+}
+
+// Host returns the address the client connects to
+func (c *Client) Host() string {
+	if c.network == "unix" {
+		return "unix://" + c.address
+	}
+	return "tcp://" + c.address
+}
+
+// Ping checks that the engine is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/_ping", nil, nil, nil)
+}
+
+// Version returns the engine's version details
+func (c *Client) Version(ctx context.Context) (*VersionInfo, error) {
+	version := &VersionInfo{}
+	err := c.do(ctx, http.MethodGet, "/version", nil, nil, version)
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// ImagePull pulls an image, returning once the engine has finished the pull. Each message about
+// one of the image's layers passes the pull's progress to progress, when it is not nil
+func (c *Client) ImagePull(ctx context.Context, image string, progress func(PullProgress)) error {
+	query := url.Values{}
+	name, tag := splitImage(image)
+	query.Set("fromImage", name)
+	query.Set("tag", tag)
+	resp, err := c.request(ctx, http.MethodPost, "/images/create", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	decoder := json.NewDecoder(resp.Body)
+	tracker := newPullTracker()
+	for {
+		message := pullMessage{}
+		err = decoder.Decode(&message)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(message.Error) > 0 {
+			return errors.New(message.Error)
+		}
+		if tracker.update(message) && progress != nil {
+			progress(tracker.progress())
+		}
+	}
+}
+
+// ImageInspect returns the details of an image held by the engine, failing with a not found
+// error when it has not been pulled
+func (c *Client) ImageInspect(ctx context.Context, image string) (*ImageJSON, error) {
+	inspect := &ImageJSON{}
+	err := c.do(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil, inspect)
+	if err != nil {
+		return nil, err
+	}
+	return inspect, nil
+}
+
+// ContainerCreate creates a container with the given name and returns its ID
+func (c *Client) ContainerCreate(ctx context.Context, name string, config *ContainerConfig) (string, error) {
+	query := url.Values{}
+	if len(name) > 0 {
+		query.Set("name", name)
+	}
+	created := &createResponse{}
+	err := c.do(ctx, http.MethodPost, "/containers/create", query, config, created)
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// ContainerStart starts a created container
+func (c *Client) ContainerStart(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil, nil)
+}
+
+// CopyToContainer writes files into a container, creating their directories, as a tar archive
+// extracted at its root
+func (c *Client) CopyToContainer(ctx context.Context, id string, files []File) error {
+	archive := &bytes.Buffer{}
+	writer := tar.NewWriter(archive)
+	created := make(map[string]bool)
+	for _, file := range files {
+		path := strings.TrimPrefix(file.Path, "/")
+		for dir := pathDir(path); len(dir) > 0 && !created[dir]; dir = pathDir(dir) {
+			created[dir] = true
+			err := writer.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0755})
+			if err != nil {
+				return err
+			}
+		}
+		err := writer.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: path, Mode: file.Mode, Size: int64(len(file.Content))})
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(file.Content)
+		if err != nil {
+			return err
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("path", "/")
+	resp, err := c.send(ctx, http.MethodPut, "/containers/"+id+"/archive", query, "application/x-tar", archive)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// pathDir is the directory of a relative, slash separated path, empty at the top
+func pathDir(path string) string {
+	index := strings.LastIndex(path, "/")
+	if index < 0 {
+		return ""
+	}
+	return path[:index]
+}
+
+// ContainerInspect returns the typed details of a container
+func (c *Client) ContainerInspect(ctx context.Context, id string) (*ContainerJSON, error) {
+	container := &ContainerJSON{}
+	err := c.do(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, container)
+	if err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+// ContainerInspectRaw returns the engine's inspect document for a container as-is
+func (c *Client) ContainerInspectRaw(ctx context.Context, id string) ([]byte, error) {
+	resp, err := c.request(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// ContainerList lists containers, optionally including stopped ones, narrowed by engine filters
+func (c *Client) ContainerList(ctx context.Context, all bool, filters map[string][]string) ([]ContainerSummary, error) {
+	query := url.Values{}
+	if all {
+		query.Set("all", "true")
+	}
+	if len(filters) > 0 {
+		encoded, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", string(encoded))
+	}
+	containers := make([]ContainerSummary, 0)
+	err := c.do(ctx, http.MethodGet, "/containers/json", query, nil, &containers)
+	if err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// ContainerLogs returns the last lines of a container's combined output; tail < 1 returns everything
+func (c *Client) ContainerLogs(ctx context.Context, id string, tail int) (string, error) {
+	query := url.Values{}
+	query.Set("stdout", "true")
+	query.Set("stderr", "true")
+	if tail > 0 {
+		query.Set("tail", strconv.Itoa(tail))
+	}
+	resp, err := c.request(ctx, http.MethodGet, "/containers/"+id+"/logs", query, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	output := &bytes.Buffer{}
+	err = demux(resp.Body, output, output)
+	if err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// ContainerStop stops a container, giving it timeout seconds before it is killed
+func (c *Client) ContainerStop(ctx context.Context, id string, timeout int) error {
+	query := url.Values{}
+	query.Set("t", strconv.Itoa(timeout))
+	err := c.do(ctx, http.MethodPost, "/containers/"+id+"/stop", query, nil, nil)
+	if IsNotModified(err) {
+		return nil
+	}
+	return err
+}
+
+// ContainerRemove removes a container along with its anonymous volumes
+func (c *Client) ContainerRemove(ctx context.Context, id string, force bool) error {
+	query := url.Values{}
+	query.Set("v", "true")
+	if force {
+		query.Set("force", "true")
+	}
+	return c.do(ctx, http.MethodDelete, "/containers/"+id, query, nil, nil)
+}
+
+// Exec runs a command inside a running container, feeding it stdin when one is given
+func (c *Client) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (*ExecResult, error) {
+	created := &execCreateResponse{}
+	err := c.do(ctx, http.MethodPost, "/containers/"+id+"/exec", nil, map[string]interface{}{
+		"AttachStdin":  stdin != nil,
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Cmd":          cmd,
+	}, created)
+	if err != nil {
+		return nil, err
+	}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err = c.execStart(ctx, created.ID, stdin, stdout, stderr)
+	if err != nil {
+		return nil, err
+	}
+	inspect := &execInspectResponse{}
+	err = c.do(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, inspect)
+	if err != nil {
+		return nil, err
+	}
+	return &ExecResult{
+		ExitCode: inspect.ExitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+// IsNotFound reports whether the engine answered that the resource does not exist
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsNotModified reports whether the engine answered that there was nothing to do
+func IsNotModified(err error) bool {
+	return hasStatus(err, http.StatusNotModified)
+}
+
+func hasStatus(err error, status int) bool {
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == status
+	}
+	return false
+}
+
+// execStart attaches to an exec instance over a hijacked connection, so that stdin can be
+// streamed in and the multiplexed output read back until the process exits
+func (c *Client) execStart(ctx context.Context, execID string, stdin io.Reader, stdout, stderr io.Writer) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	body, _ := json.Marshal(map[string]bool{"Detach": false, "Tty": false})
+	req, err := http.NewRequest(http.MethodPost, c.url("/exec/"+execID+"/start", nil), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	err = req.Write(conn)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return readAPIError(resp)
+	}
+	if stdin != nil {
+		go func() {
+			io.Copy(conn, stdin)
+			closeWrite(conn)
+		}()
+	}
+	err = demux(reader, stdout, stderr)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	return dialer.DialContext(ctx, c.network, c.address)
+}
+
+func (c *Client) url(path string, query url.Values) string {
+	result := c.base + "/" + apiVersion + path
+	if len(query) > 0 {
+		result += "?" + query.Encode()
+	}
+	return result
+}
+
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	if body == nil {
+		return c.send(ctx, method, path, query, "", nil)
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(ctx, method, path, query, "application/json", bytes.NewReader(encoded))
+}
+
+// send makes a request with a body of the given content type, or none when body is nil
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url(path, query), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest || resp.StatusCode == http.StatusNotModified {
+		defer resp.Body.Close()
+		return nil, readAPIError(resp)
+	}
+	return resp, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, result interface{}) error {
+	resp, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if result == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func readAPIError(resp *http.Response) error {
+	raw, _ := io.ReadAll(resp.Body)
+	message := struct {
+		Message string `json:"message"`
+	}{}
+	if json.Unmarshal(raw, &message) != nil || len(message.Message) < 1 {
+		message.Message = strings.TrimSpace(string(raw))
+	}
+	if len(message.Message) < 1 {
+		message.Message = http.StatusText(resp.StatusCode)
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    message.Message,
+	}
+}
+
+// demux splits the engine's multiplexed stream into stdout and stderr. Each frame carries an
+// eight byte header: the stream type, three bytes of padding and a big-endian payload size
+func demux(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		_, err := io.ReadFull(r, header)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		dest := stdout
+		if header[0] == 2 {
+			dest = stderr
+		}
+		_, err = io.CopyN(dest, r, size)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	}
+}
+
+// splitImage separates an image reference into its name and tag, defaulting the tag to latest
+func splitImage(image string) (string, string) {
+	if strings.Contains(image, "@") {
+		return image, ""
+	}
+	slash := strings.LastIndex(image, "/")
+	colon := strings.LastIndex(image, ":")
+	if colon > slash {
+		return image[:colon], image[colon+1:]
+	}
+	return image, "latest"
+}
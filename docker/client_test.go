@@ -0,0 +1,286 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewClient(t *testing.T) {
+	hosts := map[string]string{
+		"unix:///var/run/docker.sock": "unix:///var/run/docker.sock",
+		"tcp://127.0.0.1:2375":        "tcp://127.0.0.1:2375",
+	}
+	for host, expects := range hosts {
+		client, err := NewClient(host)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if client.Host() != expects {
+			t.Errorf("expected host to be '%s', got '%s'", expects, client.Host())
+		}
+	}
+	_, err := NewClient("ssh://user@remote")
+	if err == nil {
+		t.Errorf("expected an error for an unsupported scheme")
+	}
+}
+
+func TestDefaultHost(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.1:2375")
+	if DefaultHost() != "tcp://10.0.0.1:2375" {
+		t.Errorf("expected DOCKER_HOST to be honoured, got '%s'", DefaultHost())
+	}
+	t.Setenv("DOCKER_HOST", "")
+	if DefaultHost() != defaultHost {
+		t.Errorf("expected default host '%s', got '%s'", defaultHost, DefaultHost())
+	}
+}
+
+func TestClientVersion(t *testing.T) {
+	client := fakeEngine(t)
+	version, err := client.Version(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Version != "24.0.5" || version.GitCommit != "ced0996" {
+		t.Errorf("unexpected version %+v", version)
+	}
+}
+
+func TestClientContainerList(t *testing.T) {
+	client := fakeEngine(t)
+	containers, err := client.ContainerList(context.Background(), true, map[string][]string{"name": {"TrySql-abc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 1 || containers[0].Names[0] != "/TrySql-abc" {
+		t.Errorf("expected the filtered container, got %+v", containers)
+	}
+}
+
+func TestClientNotFound(t *testing.T) {
+	client := fakeEngine(t)
+	_, err := client.ContainerInspect(context.Background(), "missing")
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "No such container: missing") {
+		t.Errorf("expected the engine's message in the error, got '%s'", err.Error())
+	}
+}
+
+func TestClientImageInspect(t *testing.T) {
+	client := fakeEngine(t)
+	image, err := client.ImageInspect(context.Background(), "mysql/mysql-server:8.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.ID != "sha256:5fbe" || image.Digest("mysql/mysql-server:8.0") != "sha256:9a1b" {
+		t.Errorf("expected the image's ID and the digest of its own repository, got %s and %s", image.ID, image.Digest("mysql/mysql-server:8.0"))
+	}
+	if image.Digest("mirror.local/mysql-server:8.0") != "sha256:7c2d" {
+		t.Errorf("expected the first digest for an image from another repository, got %s", image.Digest("mirror.local/mysql-server:8.0"))
+	}
+	_, err = client.ImageInspect(context.Background(), "postgres:16")
+	if !IsNotFound(err) {
+		t.Errorf("expected an image that was never pulled not to be found, got %v", err)
+	}
+}
+
+func TestClientImagePull(t *testing.T) {
+	client := fakeEngine(t)
+	percents := []int{}
+	err := client.ImagePull(context.Background(), "mysql:8.0", func(progress PullProgress) {
+		percents = append(percents, progress.Percent())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(percents) != "[0 50 100]" {
+		t.Errorf("expected progress for each message about a layer, got %v", percents)
+	}
+	err = client.ImagePull(context.Background(), "mysql:missing", nil)
+	if err == nil || err.Error() != "manifest for mysql:missing not found" {
+		t.Errorf("expected the engine's error from the pull's stream, got %v", err)
+	}
+}
+
+func TestClientExec(t *testing.T) {
+	client := fakeEngine(t)
+	result, err := client.Exec(context.Background(), "abc", []string{"cat"}, strings.NewReader("SELECT 1;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stdout != "SELECT 1;" {
+		t.Errorf("expected stdin to be echoed to stdout, got '%s'", result.Stdout)
+	}
+	if result.Stderr != "warning" {
+		t.Errorf("expected stderr to be 'warning', got '%s'", result.Stderr)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", result.ExitCode)
+	}
+}
+
+func TestClientCopyToContainer(t *testing.T) {
+	client := fakeEngine(t)
+	err := client.CopyToContainer(context.Background(), "abc", []File{
+		{Path: "/run/trysql/password", Mode: 0444, Content: []byte("s3cret")},
+		{Path: "/run/trysql/client.cnf", Mode: 0400, Content: []byte("[client]\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := map[string]string{
+		"run/":                  "dir 755",
+		"run/trysql/":           "dir 755",
+		"run/trysql/password":   "file 444 s3cret",
+		"run/trysql/client.cnf": "file 400 [client]\n",
+	}
+	if len(archived) != len(expects) {
+		t.Errorf("expected %d entries in the archive, got %v", len(expects), archived)
+	}
+	for name, entry := range expects {
+		if archived[name] != entry {
+			t.Errorf("expected '%s' to be archived as '%s', got '%s'", name, entry, archived[name])
+		}
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	cases := map[string][2]string{
+		"mysql/mysql-server:8.0":    {"mysql/mysql-server", "8.0"},
+		"mysql":                     {"mysql", "latest"},
+		"localhost:5000/mysql":      {"localhost:5000/mysql", "latest"},
+		"localhost:5000/mysql:8.0":  {"localhost:5000/mysql", "8.0"},
+		"mysql@sha256:0123456789ab": {"mysql@sha256:0123456789ab", ""},
+	}
+	for image, expects := range cases {
+		name, tag := splitImage(image)
+		if name != expects[0] || tag != expects[1] {
+			t.Errorf("expected '%s' to split into %v, got [%s %s]", image, expects, name, tag)
+		}
+	}
+}
+
+// archived holds the entries of the last archive copied into the fake engine's container
+var archived map[string]string
+
+// fakeEngine serves a minimal engine API on a unix socket for the duration of the test
+func fakeEngine(t *testing.T) *Client {
+	dir, err := os.MkdirTemp("", "engine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+apiVersion+"/version", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(VersionInfo{Version: "24.0.5", GitCommit: "ced0996"})
+	})
+	mux.HandleFunc("/"+apiVersion+"/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		filters := map[string][]string{}
+		json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		containers := []ContainerSummary{}
+		if r.URL.Query().Get("all") == "true" && len(filters["name"]) > 0 {
+			containers = append(containers, ContainerSummary{ID: "abc", Names: []string{"/" + filters["name"][0]}})
+		}
+		json.NewEncoder(w).Encode(containers)
+	})
+	mux.HandleFunc("/"+apiVersion+"/containers/missing/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"No such container: missing"}`)
+	})
+	mux.HandleFunc("/"+apiVersion+"/images/create", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("tag") == "missing" {
+			fmt.Fprintln(w, `{"status":"Pulling from library/mysql","id":"missing"}`)
+			fmt.Fprintln(w, `{"errorDetail":{"message":"manifest for mysql:missing not found"},"error":"manifest for mysql:missing not found"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":"Pulling from library/mysql","id":"8.0"}`)
+		fmt.Fprintln(w, `{"status":"Pulling fs layer","id":"a1"}`)
+		fmt.Fprintln(w, `{"status":"Downloading","progressDetail":{"current":50,"total":100},"id":"a1"}`)
+		fmt.Fprintln(w, `{"status":"Pull complete","id":"a1"}`)
+		fmt.Fprintln(w, `{"status":"Status: Downloaded newer image for mysql:8.0"}`)
+	})
+	mux.HandleFunc("/"+apiVersion+"/images/mysql/mysql-server:8.0/json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ImageJSON{
+			ID:          "sha256:5fbe",
+			RepoTags:    []string{"mysql/mysql-server:8.0"},
+			RepoDigests: []string{"mirror.local/mysql@sha256:7c2d", "mysql/mysql-server@sha256:9a1b"},
+		})
+	})
+	mux.HandleFunc("/"+apiVersion+"/images/postgres:16/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"No such image: postgres:16"}`)
+	})
+	mux.HandleFunc("/"+apiVersion+"/containers/abc/exec", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"Id":"exec1"}`)
+	})
+	mux.HandleFunc("/"+apiVersion+"/exec/exec1/start", func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		stdin, _ := io.ReadAll(bufio.NewReader(io.MultiReader(buf, conn)))
+		writeFrame(conn, 1, stdin)
+		writeFrame(conn, 2, []byte("warning"))
+	})
+	mux.HandleFunc("/"+apiVersion+"/containers/abc/archive", func(w http.ResponseWriter, r *http.Request) {
+		archived = make(map[string]string)
+		reader := tar.NewReader(r.Body)
+		for {
+			header, err := reader.Next()
+			if err != nil {
+				break
+			}
+			if header.Typeflag == tar.TypeDir {
+				archived[header.Name] = fmt.Sprintf("dir %o", header.Mode)
+				continue
+			}
+			content, _ := io.ReadAll(reader)
+			archived[header.Name] = fmt.Sprintf("file %o %s", header.Mode, content)
+		}
+	})
+	mux.HandleFunc("/"+apiVersion+"/exec/exec1/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Running":false,"ExitCode":3}`)
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+	client, err := NewClient("unix://" + socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func writeFrame(w io.Writer, stream byte, payload []byte) {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	w.Write(header)
+	w.Write(payload)
+}
@@ -1,85 +1,103 @@
 package docker
 
 import (
-	"errors"
-	"os/exec"
-	"strings"
+	"context"
+	"fmt"
+	"io"
 
 	"github.com/blainemoser/TrySql/configs"
-	"github.com/blainemoser/TrySql/utils"
 )
 
+// Docker is the container runtime backed by a Docker Engine API compatible host
 type Docker struct {
-	Version   string
-	Password  string
-	HostPort  int
-	RunAsSudo bool
+	Client  *Client
+	version string
 }
 
-type command struct {
-	inputs []string
-	d      *Docker
+func New(configs *configs.Configs) (*Docker, error) {
+	return Connect(DefaultHost())
 }
 
-func New(configs *configs.Configs) (*Docker, error) {
-	owner, err := utils.GetProcessOwner()
+// Connect creates a runtime for the engine listening on host
+func Connect(host string) (*Docker, error) {
+	client, err := NewClient(host)
 	if err != nil {
 		return nil, err
 	}
-	password, _ := utils.MakePass()
-	return &Docker{
-		RunAsSudo: owner != "root",
-		Password:  password,
-		HostPort:  configs.GetPort(),
-	}, nil
-}
-
-func (d *Docker) Com() *command {
-	var inputs []string
-	if d.RunAsSudo {
-		inputs = []string{"sudo", "docker"}
-	} else {
-		inputs = []string{"docker"}
-	}
-	com := &command{
-		inputs: inputs,
-		d:      d,
-	}
-	return com
+	return &Docker{Client: client}, nil
 }
 
-func (d *Docker) SetVersion() error {
-	result, err := d.Com().Args([]string{"-v"}).Exec()
-	if err != nil {
-		return err
-	}
-	d.Version = strings.Replace(result, "\n", "", -1)
-	return nil
+func (d *Docker) Name() string {
+	return "docker"
 }
 
-func (c *command) Args(args []string) *command {
-	c.inputs = append(c.inputs, args...)
-	return c
+// Host returns the address of the engine, e.g. unix:///var/run/docker.sock
+func (d *Docker) Host() string {
+	return d.Client.Host()
 }
 
-func (c *command) Exec() (string, error) {
-	result, err := exec.Command(c.inputs[0], c.inputs[1:]...).CombinedOutput()
+// Version asks the engine for its version, reported in the same form as docker -v
+func (d *Docker) Version(ctx context.Context) (string, error) {
+	if len(d.version) > 0 {
+		return d.version, nil
+	}
+	version, err := d.Client.Version(ctx)
 	if err != nil {
-		return "", errors.New(err.Error() + ": " + string(result))
+		return "", fmt.Errorf("could not reach the docker engine at %s: %s", d.Client.Host(), err.Error())
 	}
-	return string(result), nil
+	d.version = fmt.Sprintf("Docker version %s, build %s", version.Version, version.GitCommit)
+	return d.version, nil
 }
 
-func (c *command) ExecRaw(arg string) (string, error) {
-	var result []byte
-	var err error
-	if c.d.RunAsSudo {
-		result, err = exec.Command("sh", "-c", c.inputs[0]+" "+c.inputs[1]+" "+arg).CombinedOutput()
-	} else {
-		result, err = exec.Command("sh", "-c", c.inputs[0]+" "+arg).CombinedOutput()
-	}
+func (d *Docker) Pull(ctx context.Context, image string, progress func(PullProgress)) error {
+	return d.Client.ImagePull(ctx, image, progress)
+}
+
+// InspectImage returns the engine's copy of an image, failing with a not found error when
+// there is none
+func (d *Docker) InspectImage(ctx context.Context, image string) (*ImageJSON, error) {
+	return d.Client.ImageInspect(ctx, image)
+}
+
+// Run creates the container, writes its files and then starts it
+func (d *Docker) Run(ctx context.Context, name string, config *ContainerConfig) (string, error) {
+	id, err := d.Client.ContainerCreate(ctx, name, config)
 	if err != nil {
-		return "", errors.New(err.Error() + ": " + string(result))
+		return "", err
+	}
+	if len(config.Files) > 0 {
+		err = d.Client.CopyToContainer(ctx, id, config.Files)
+		if err != nil {
+			return id, err
+		}
 	}
-	return string(result), nil
+	return id, d.Client.ContainerStart(ctx, id)
+}
+
+func (d *Docker) List(ctx context.Context, all bool, filters map[string][]string) ([]ContainerSummary, error) {
+	return d.Client.ContainerList(ctx, all, filters)
+}
+
+func (d *Docker) Inspect(ctx context.Context, id string) (*ContainerJSON, error) {
+	return d.Client.ContainerInspect(ctx, id)
+}
+
+func (d *Docker) InspectRaw(ctx context.Context, id string) ([]byte, error) {
+	return d.Client.ContainerInspectRaw(ctx, id)
+}
+
+func (d *Docker) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (*ExecResult, error) {
+	return d.Client.Exec(ctx, id, cmd, stdin)
+}
+
+func (d *Docker) Logs(ctx context.Context, id string, tail int) (string, error) {
+	return d.Client.ContainerLogs(ctx, id, tail)
+}
+
+func (d *Docker) Stop(ctx context.Context, id string, timeout int) error {
+	return d.Client.ContainerStop(ctx, id, timeout)
+}
+
+func (d *Docker) Remove(ctx context.Context, id string, force bool) error {
+	return d.Client.ContainerRemove(ctx, id, force)
 }
@@ -0,0 +1,132 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LayerProgress is how much of one layer of an image has been downloaded. Total is zero until the
+// registry has told the engine the layer's size, and layers the engine already held are Done at once
+type LayerProgress struct {
+	ID      string
+	Current int64
+	Total   int64
+	Done    bool
+}
+
+func (l LayerProgress) String() string {
+	switch {
+	case l.Done:
+		return l.ID + "  done"
+	case l.Total > 0:
+		return fmt.Sprintf("%s  %s of %s", l.ID, formatBytes(l.Current), formatBytes(l.Total))
+	}
+	return l.ID + "  waiting"
+}
+
+// PullProgress is the state of a pull over the layers the engine has announced so far, in the
+// order it announced them
+type PullProgress struct {
+	Layers []LayerProgress
+}
+
+// Bytes returns how much has been downloaded of the layers whose sizes are known
+func (p PullProgress) Bytes() (int64, int64) {
+	var current, total int64
+	for _, layer := range p.Layers {
+		current += layer.Current
+		total += layer.Total
+	}
+	return current, total
+}
+
+// Percent returns how much of the known bytes have been downloaded, 100 once every layer is done
+func (p PullProgress) Percent() int {
+	done := len(p.Layers) > 0
+	for _, layer := range p.Layers {
+		done = done && layer.Done
+	}
+	if done {
+		return 100
+	}
+	current, total := p.Bytes()
+	if total < 1 {
+		return 0
+	}
+	percent := int(current * 100 / total)
+	if percent > 99 {
+		return 99
+	}
+	return percent
+}
+
+// String summarises the pull on its first line, followed by a line per layer
+func (p PullProgress) String() string {
+	done := 0
+	for _, layer := range p.Layers {
+		if layer.Done {
+			done++
+		}
+	}
+	current, total := p.Bytes()
+	lines := []string{fmt.Sprintf("%s of %s, %d of %d layers", formatBytes(current), formatBytes(total), done, len(p.Layers))}
+	for _, layer := range p.Layers {
+		lines = append(lines, layer.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pullTracker follows the messages of a pull, which report on each layer by its ID
+type pullTracker struct {
+	layers []LayerProgress
+	index  map[string]int
+}
+
+func newPullTracker() *pullTracker {
+	return &pullTracker{index: make(map[string]int)}
+}
+
+// update applies a message, returning whether it was about a layer. Downloaded layers go on to
+// be extracted, with progress of its own that is not counted
+func (p *pullTracker) update(message pullMessage) bool {
+	if len(message.ID) < 1 || strings.HasPrefix(message.Status, "Pulling from") {
+		return false
+	}
+	i, ok := p.index[message.ID]
+	if !ok {
+		i = len(p.layers)
+		p.index[message.ID] = i
+		p.layers = append(p.layers, LayerProgress{ID: message.ID})
+	}
+	layer := &p.layers[i]
+	switch message.Status {
+	case "Downloading":
+		layer.Current = message.ProgressDetail.Current
+		layer.Total = message.ProgressDetail.Total
+	case "Download complete", "Extracting", "Pull complete", "Already exists":
+		layer.Current = layer.Total
+		layer.Done = true
+	}
+	return true
+}
+
+func (p *pullTracker) progress() PullProgress {
+	layers := make([]LayerProgress, len(p.layers))
+	copy(layers, p.layers)
+	return PullProgress{Layers: layers}
+}
+
+// formatBytes writes a size in decimal units, as the docker command line does
+func formatBytes(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d%s", size, units[unit])
+	}
+	return fmt.Sprintf("%.1f%s", value, units[unit])
+}
@@ -0,0 +1,55 @@
+package docker
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestPullTracker(t *testing.T) {
+	defer utils.HandelPanic(t)
+	tracker := newPullTracker()
+	messages := []string{
+		`{"status":"Pulling from library/mysql","id":"8.0"}`,
+		`{"status":"Already exists","id":"a1"}`,
+		`{"status":"Pulling fs layer","id":"b2"}`,
+		`{"status":"Pulling fs layer","id":"c3"}`,
+		`{"status":"Downloading","progressDetail":{"current":250000,"total":1000000},"id":"b2"}`,
+		`{"status":"Downloading","progressDetail":{"current":500,"total":1000000},"id":"c3"}`,
+	}
+	layers := 0
+	for _, message := range messages {
+		if tracker.update(decodePullMessage(t, message)) {
+			layers++
+		}
+	}
+	if layers != 5 {
+		t.Errorf("expected 5 messages about layers, got %d", layers)
+	}
+	progress := tracker.progress()
+	if progress.Percent() != 12 {
+		t.Errorf("expected 12 percent, got %d", progress.Percent())
+	}
+	expects := "250.5kB of 2.0MB, 1 of 3 layers\na1  done\nb2  250.0kB of 1.0MB\nc3  500B of 1.0MB"
+	if progress.String() != expects {
+		t.Errorf("expected '%s', got '%s'", expects, progress.String())
+	}
+	tracker.update(decodePullMessage(t, `{"status":"Extracting","progressDetail":{"current":10,"total":1000000},"id":"b2"}`))
+	tracker.update(decodePullMessage(t, `{"status":"Download complete","id":"c3"}`))
+	if tracker.progress().Percent() != 100 {
+		t.Errorf("expected 100 percent once every layer is downloaded, got %d", tracker.progress().Percent())
+	}
+	if (PullProgress{}).Percent() != 0 {
+		t.Errorf("expected a pull with no layers yet to be at 0 percent")
+	}
+}
+
+func decodePullMessage(t *testing.T, raw string) pullMessage {
+	message := pullMessage{}
+	err := json.Unmarshal([]byte(raw), &message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
@@ -0,0 +1,207 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blainemoser/TrySql/utils"
+)
+
+// VersionInfo is the response of the engine's /version endpoint
+type VersionInfo struct {
+	Version       string `json:"Version"`
+	APIVersion    string `json:"ApiVersion"`
+	MinAPIVersion string `json:"MinAPIVersion"`
+	GitCommit     string `json:"GitCommit"`
+	Os            string `json:"Os"`
+	Arch          string `json:"Arch"`
+}
+
+// ImageJSON is the response of an image inspect
+type ImageJSON struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Created     string   `json:"Created"`
+	Size        int64    `json:"Size"`
+}
+
+// Digest returns the registry digest of the image, preferring the one of the repository image
+// was pulled from. Images that were built locally and never pushed have none
+func (i *ImageJSON) Digest(image string) string {
+	name, _ := splitImage(image)
+	digest := ""
+	for _, repoDigest := range i.RepoDigests {
+		repository, found, ok := strings.Cut(repoDigest, "@")
+		if !ok {
+			continue
+		}
+		if repository == name {
+			return found
+		}
+		if len(digest) < 1 {
+			digest = found
+		}
+	}
+	return digest
+}
+
+// PortBinding maps a container port onto the host
+type PortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// HostConfig is the subset of the engine's host configuration used by TrySql
+type HostConfig struct {
+	PortBindings map[string][]PortBinding `json:"PortBindings,omitempty"`
+	AutoRemove   bool                     `json:"AutoRemove,omitempty"`
+	Memory       int64                    `json:"Memory,omitempty"`
+	NanoCPUs     int64                    `json:"NanoCpus,omitempty"`
+}
+
+// File is written into a container before it starts
+type File struct {
+	Path    string
+	Mode    int64
+	Content []byte
+}
+
+// ContainerConfig is the body sent when creating a container. Files are not part of the body but
+// are copied in between creating and starting the container, keeping secrets out of its config
+type ContainerConfig struct {
+	Image        string              `json:"Image"`
+	Env          []string            `json:"Env,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	HostConfig   *HostConfig         `json:"HostConfig,omitempty"`
+	Files        []File              `json:"-"`
+}
+
+// Port is a port of a listed container, PublicPort being the host's side when it is published
+type Port struct {
+	IP          string `json:"IP,omitempty"`
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort,omitempty"`
+	Type        string `json:"Type"`
+}
+
+// MountPoint is a volume or bind mount of a container. Name is set for volumes, which are
+// anonymous unless the container was created with a named one
+type MountPoint struct {
+	Type        string `json:"Type"`
+	Name        string `json:"Name,omitempty"`
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+}
+
+// ContainerSummary is a single entry of the engine's container list
+type ContainerSummary struct {
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	ImageID string            `json:"ImageID"`
+	Created int64             `json:"Created"`
+	State   string            `json:"State"`
+	Status  string            `json:"Status"`
+	Labels  map[string]string `json:"Labels"`
+	Ports   []Port            `json:"Ports"`
+	Mounts  []MountPoint      `json:"Mounts"`
+}
+
+// HealthLog is one result of a container HEALTHCHECK
+type HealthLog struct {
+	Start    string `json:"Start"`
+	End      string `json:"End"`
+	ExitCode int    `json:"ExitCode"`
+	Output   string `json:"Output"`
+}
+
+// Health is the HEALTHCHECK state of a container
+type Health struct {
+	Status        string      `json:"Status"`
+	FailingStreak int         `json:"FailingStreak"`
+	Log           []HealthLog `json:"Log"`
+}
+
+// ContainerState is the runtime state of a container
+type ContainerState struct {
+	Status     string  `json:"Status"`
+	Running    bool    `json:"Running"`
+	Paused     bool    `json:"Paused"`
+	Restarting bool    `json:"Restarting"`
+	OOMKilled  bool    `json:"OOMKilled"`
+	Dead       bool    `json:"Dead"`
+	Pid        int     `json:"Pid"`
+	ExitCode   int     `json:"ExitCode"`
+	Error      string  `json:"Error"`
+	StartedAt  string  `json:"StartedAt"`
+	FinishedAt string  `json:"FinishedAt"`
+	Health     *Health `json:"Health,omitempty"`
+}
+
+// NetworkSettings holds the published ports of a container
+type NetworkSettings struct {
+	Ports map[string][]PortBinding `json:"Ports"`
+}
+
+// ContainerJSON is the response of a container inspect
+type ContainerJSON struct {
+	ID              string           `json:"Id"`
+	Name            string           `json:"Name"`
+	Image           string           `json:"Image"`
+	Created         string           `json:"Created"`
+	State           *ContainerState  `json:"State"`
+	Config          *ContainerConfig `json:"Config"`
+	NetworkSettings *NetworkSettings `json:"NetworkSettings"`
+}
+
+// ExecResult is the outcome of a command run inside a container
+type ExecResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// APIError is returned when the engine responds with a non-success status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docker engine responded with %d: %s", e.StatusCode, e.Message)
+}
+
+// Redact returns a copy with the secrets hidden in the engine's message
+func (e *APIError) Redact(secrets ...string) error {
+	return &APIError{StatusCode: e.StatusCode, Message: utils.Redact(e.Message, secrets...)}
+}
+
+type createResponse struct {
+	ID       string   `json:"Id"`
+	Warnings []string `json:"Warnings"`
+}
+
+type execCreateResponse struct {
+	ID string `json:"Id"`
+}
+
+type execInspectResponse struct {
+	Running  bool `json:"Running"`
+	ExitCode int  `json:"ExitCode"`
+}
+
+type pullMessage struct {
+	Status         string `json:"status"`
+	ID             string `json:"id"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
@@ -0,0 +1,107 @@
+package engines
+
+// MariaDB runs the official mariadb image. It has no HEALTHCHECK, so readiness is probed
+// with mariadb-admin, and its tools are named mariadb rather than mysql as of 11.x
+type MariaDB struct {
+	version string
+}
+
+func (m *MariaDB) Name() string {
+	return "mariadb"
+}
+
+func (m *MariaDB) Protocol() string {
+	return ProtocolMySQL
+}
+
+func (m *MariaDB) Image() string {
+	return "mariadb:" + m.version
+}
+
+func (m *MariaDB) Port() string {
+	return "3306/tcp"
+}
+
+func (m *MariaDB) Env() []string {
+	return []string{
+		"MARIADB_ROOT_HOST=%",
+		"MARIADB_ROOT_PASSWORD_FILE=" + PasswordFile,
+	}
+}
+
+func (m *MariaDB) Files(password string) map[string]string {
+	return mysqlFiles(password)
+}
+
+func (m *MariaDB) Client() string {
+	return "mariadb"
+}
+
+func (m *MariaDB) ClientArgs() []string {
+	return []string{
+		m.Client(),
+		"--defaults-extra-file=" + OptionFile,
+		"--batch",
+	}
+}
+
+func (m *MariaDB) ShellArgs() []string {
+	return []string{
+		m.Client(),
+		"--defaults-extra-file=" + OptionFile,
+	}
+}
+
+func (m *MariaDB) PingArgs() []string {
+	return []string{
+		"mariadb-admin",
+		"--defaults-extra-file=" + OptionFile,
+		"ping",
+		"--host=127.0.0.1",
+		"--protocol=tcp",
+	}
+}
+
+func (m *MariaDB) ServerArgs(variables map[string]string) []string {
+	return mysqlServerArgs(variables)
+}
+
+func (m *MariaDB) CreateDatabase(name string) string {
+	return "CREATE DATABASE IF NOT EXISTS `" + name + "`;"
+}
+
+func (m *MariaDB) CreateUser(name, password string, databases []string) string {
+	return mysqlCreateUser(name, password, databases)
+}
+
+func (m *MariaDB) Readiness() string {
+	return ReadyPing
+}
+
+func (m *MariaDB) Warnings() []string {
+	return nil
+}
+
+func (m *MariaDB) User() string {
+	return "root"
+}
+
+func (m *MariaDB) Driver() string {
+	return "mysql"
+}
+
+func (m *MariaDB) ConnectCommand(endpoint Endpoint) string {
+	return mysqlConnectCommand(m.Client(), m.User(), endpoint)
+}
+
+func (m *MariaDB) DSN(endpoint Endpoint, params map[string]string) (string, error) {
+	return mysqlDSN(m.User(), endpoint, params)
+}
+
+func (m *MariaDB) URI(endpoint Endpoint) string {
+	return engineURI("mariadb", m.User(), "/", endpoint)
+}
+
+func (m *MariaDB) JDBC(endpoint Endpoint) string {
+	return engineJDBC("mariadb", m.User(), "/", endpoint)
+}
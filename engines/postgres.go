@@ -0,0 +1,160 @@
+package engines
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// Postgres runs the official postgres image. Inside the container psql connects over the local
+// socket, which the image trusts, so queries need no password
+type Postgres struct {
+	version string
+}
+
+func (p *Postgres) Name() string {
+	return "postgres"
+}
+
+func (p *Postgres) Protocol() string {
+	return ProtocolPostgres
+}
+
+func (p *Postgres) Image() string {
+	return "postgres:" + p.version
+}
+
+func (p *Postgres) Port() string {
+	return "5432/tcp"
+}
+
+func (p *Postgres) Env() []string {
+	return []string{
+		"POSTGRES_PASSWORD_FILE=" + PasswordFile,
+	}
+}
+
+// Files holds only the password, since psql in the container connects over the trusted socket
+func (p *Postgres) Files(password string) map[string]string {
+	return map[string]string{PasswordFile: password}
+}
+
+func (p *Postgres) Client() string {
+	return "psql"
+}
+
+// ClientArgs prints unaligned, tab separated rows with a header, the same shape as mysql's batch output
+func (p *Postgres) ClientArgs() []string {
+	return []string{
+		p.Client(),
+		"--username=postgres",
+		"--no-psqlrc",
+		"--no-align",
+		"--field-separator=\t",
+		"--pset=footer=off",
+		"--set=ON_ERROR_STOP=1",
+		"--set=VERBOSITY=verbose",
+		"--file=-",
+	}
+}
+
+func (p *Postgres) ShellArgs() []string {
+	return []string{
+		p.Client(),
+		"--username=postgres",
+	}
+}
+
+// PingArgs checks over tcp, since the entrypoint's temporary server during initialisation only listens on the socket
+func (p *Postgres) PingArgs() []string {
+	return []string{
+		"pg_isready",
+		"--host=127.0.0.1",
+		"--username=postgres",
+	}
+}
+
+// ServerArgs sets each variable with -c, which the image's entrypoint hands on to postgres
+func (p *Postgres) ServerArgs(variables map[string]string) []string {
+	args := []string{"postgres"}
+	for _, name := range sortedNames(variables) {
+		args = append(args, "-c", fmt.Sprintf("%s=%s", name, variables[name]))
+	}
+	return args
+}
+
+// CreateDatabase has psql run the CREATE DATABASE only when the select finds it missing, since
+// postgres has no IF NOT EXISTS for databases
+func (p *Postgres) CreateDatabase(name string) string {
+	return fmt.Sprintf(
+		"SELECT 'CREATE DATABASE \"%s\"' WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = '%s')\\gexec\n",
+		name,
+		name,
+	)
+}
+
+// CreateUser makes the user the owner of the databases, which since postgres 15 is what allows
+// it to create tables in their public schemas
+func (p *Postgres) CreateUser(name, password string, databases []string) string {
+	statements := []string{fmt.Sprintf(
+		"SELECT 'CREATE ROLE \"%s\" LOGIN' WHERE NOT EXISTS (SELECT FROM pg_roles WHERE rolname = '%s')\\gexec",
+		name,
+		name,
+	)}
+	statements = append(statements, fmt.Sprintf("ALTER ROLE \"%s\" PASSWORD '%s';", name, quote(password)))
+	for _, database := range databases {
+		statements = append(statements, fmt.Sprintf("ALTER DATABASE \"%s\" OWNER TO \"%s\";", database, name))
+	}
+	return strings.Join(statements, "\n") + "\n"
+}
+
+func (p *Postgres) Readiness() string {
+	return ReadyPing
+}
+
+func (p *Postgres) Warnings() []string {
+	return nil
+}
+
+func (p *Postgres) User() string {
+	return "postgres"
+}
+
+func (p *Postgres) Driver() string {
+	return "postgres"
+}
+
+// DSN is a URL, with SSL off since the image does not set up certificates. The params are lib/pq's
+func (p *Postgres) DSN(endpoint Endpoint, params map[string]string) (string, error) {
+	dsn, err := url.Parse(p.URI(endpoint))
+	if err != nil {
+		return "", err
+	}
+	values := url.Values{}
+	values.Set("sslmode", "disable")
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	dsn.RawQuery = values.Encode()
+	return dsn.String(), nil
+}
+
+func (p *Postgres) URI(endpoint Endpoint) string {
+	return engineURI("postgres", p.User(), "/postgres", endpoint)
+}
+
+func (p *Postgres) JDBC(endpoint Endpoint) string {
+	return engineJDBC("postgresql", p.User(), "/postgres", endpoint)
+}
+
+func (p *Postgres) ConnectCommand(endpoint Endpoint) string {
+	return fmt.Sprintf(
+		"%s -h%s -p%s -U%s",
+		p.Client(),
+		endpoint.Host,
+		endpoint.Port,
+		p.User(),
+	)
+}
@@ -0,0 +1,90 @@
+package engines
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// The code a postgres client sends to ask whether the server supports SSL
+const sslRequestCode = 80877103
+
+// Dial checks from the host that the server is accepting connections. Docker's port proxy
+// accepts connections before the server is listening, so a connection alone proves nothing:
+// the server has to answer in its own protocol
+func Dial(engine Engine, address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return err
+	}
+	if engine.Protocol() == ProtocolPostgres {
+		return postgresHandshake(conn)
+	}
+	return mysqlHandshake(conn)
+}
+
+// Query checks from the host that the server answers SELECT 1 to the superuser, the same round
+// trip a test's first query makes. The handshake is checked first, so that the driver is not
+// left to log the connections Docker's port proxy drops while the server is starting
+func Query(ctx context.Context, engine Engine, endpoint Endpoint, timeout time.Duration) error {
+	err := Dial(engine, endpoint.Address(), timeout)
+	if err != nil {
+		return err
+	}
+	dsn, err := engine.DSN(endpoint, nil)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open(engine.Driver(), dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var one int
+	return db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// mysqlHandshake reads the greeting a MySQL server sends on connect: a protocol 10 handshake,
+// or an error packet when the host is refused, which still means the server is up
+func mysqlHandshake(conn net.Conn) error {
+	packet := make([]byte, 5)
+	_, err := io.ReadFull(conn, packet)
+	if err != nil {
+		return err
+	}
+	if packet[4] != 10 && packet[4] != 0xff {
+		return fmt.Errorf("unexpected mysql greeting %#x", packet[4])
+	}
+	return nil
+}
+
+// postgresHandshake sends an SSLRequest, which a postgres server answers with a single S or N
+func postgresHandshake(conn net.Conn) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:], 8)
+	binary.BigEndian.PutUint32(request[4:], sslRequestCode)
+	_, err := conn.Write(request)
+	if err != nil {
+		return err
+	}
+	answer := make([]byte, 1)
+	_, err = io.ReadFull(conn, answer)
+	if err != nil {
+		return err
+	}
+	if answer[0] != 'S' && answer[0] != 'N' {
+		return fmt.Errorf("unexpected postgres answer %q", answer[0])
+	}
+	return nil
+}
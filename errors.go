@@ -0,0 +1,144 @@
+package trysql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blainemoser/TrySql/engines"
+	"github.com/blainemoser/TrySql/utils"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// The mysql and mariadb clients report errors as: ERROR 1146 (42S02) at line 1: Table 't' doesn't exist
+var mysqlClientError = regexp.MustCompile(`^ERROR (\d+) \(([0-9A-Z]{5})\)(?: at line \d+)?: (.*)$`)
+
+// psql, with verbose errors, reports them as: psql:<stdin>:1: ERROR:  42P01: relation "t" does not exist
+var postgresClientError = regexp.MustCompile(`^(?:psql:[^:]*:\d+: )?ERROR:\s+([0-9A-Z]{5}): (.*)$`)
+
+// QueryError is an error reported by the database server. Number is the MySQL error number,
+// such as 1062 for a duplicate entry, and is zero for postgres, which only reports a SQLSTATE
+type QueryError struct {
+	Number   int
+	SQLState string
+	Message  string
+	err      error
+}
+
+func (e *QueryError) Error() string {
+	if e.Number > 0 {
+		return fmt.Sprintf("ERROR %d (%s): %s", e.Number, e.SQLState, e.Message)
+	}
+	return fmt.Sprintf("ERROR (%s): %s", e.SQLState, e.Message)
+}
+
+// Unwrap returns the driver's own error for failures from QueryRows
+func (e *QueryError) Unwrap() error {
+	return e.err
+}
+
+// Redact returns a copy with the secrets hidden in its message and the driver's error
+func (e *QueryError) Redact(secrets ...string) error {
+	redacted := *e
+	redacted.Message = utils.Redact(e.Message, secrets...)
+	redacted.err = utils.RedactError(e.err, secrets...)
+	return &redacted
+}
+
+// StartupError is returned when the container stops before the server is ready. Logs is the end
+// of the server's log, which usually says why
+type StartupError struct {
+	Status    string
+	ExitCode  int
+	OOMKilled bool
+	Logs      string
+}
+
+func (e *StartupError) Error() string {
+	message := fmt.Sprintf("container %s with exit code %d while starting", e.Status, e.ExitCode)
+	if e.OOMKilled {
+		message += ", out of memory"
+	}
+	if len(e.Logs) > 0 {
+		message += "; last lines of its log:\n" + e.Logs
+	}
+	return message
+}
+
+// Redact returns a copy with the secrets hidden in its logs
+func (e *StartupError) Redact(secrets ...string) error {
+	redacted := *e
+	redacted.Logs = utils.Redact(e.Logs, secrets...)
+	return &redacted
+}
+
+// exitError is a command in the container exiting non-zero
+type exitError struct {
+	code   int
+	stderr string
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d: %s", e.code, e.stderr)
+}
+
+func (e *exitError) Redact(secrets ...string) error {
+	return &exitError{code: e.code, stderr: utils.Redact(e.stderr, secrets...)}
+}
+
+// clientError finds the server's error in a failed client's output, returning err unchanged
+// when the failure was not reported by the server
+func (ts *TrySql) clientError(err error) error {
+	exit := &exitError{}
+	if !errors.As(err, &exit) {
+		return err
+	}
+	for _, line := range strings.Split(exit.stderr, "\n") {
+		queryErr := parseClientError(ts.engine.Protocol(), strings.TrimSpace(line))
+		if queryErr != nil {
+			return queryErr
+		}
+	}
+	return err
+}
+
+func parseClientError(protocol, line string) *QueryError {
+	if protocol == engines.ProtocolPostgres {
+		match := postgresClientError.FindStringSubmatch(line)
+		if match == nil {
+			return nil
+		}
+		return &QueryError{SQLState: match[1], Message: match[2]}
+	}
+	match := mysqlClientError.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+	number, _ := strconv.Atoi(match[1])
+	return &QueryError{Number: number, SQLState: match[2], Message: match[3]}
+}
+
+// driverError converts the drivers' server errors into a QueryError
+func driverError(err error) error {
+	mysqlErr := &mysql.MySQLError{}
+	if errors.As(err, &mysqlErr) {
+		return &QueryError{
+			Number:   int(mysqlErr.Number),
+			SQLState: string(mysqlErr.SQLState[:]),
+			Message:  mysqlErr.Message,
+			err:      err,
+		}
+	}
+	pqErr := &pq.Error{}
+	if errors.As(err, &pqErr) {
+		return &QueryError{
+			SQLState: string(pqErr.Code),
+			Message:  pqErr.Message,
+			err:      err,
+		}
+	}
+	return err
+}
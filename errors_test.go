@@ -0,0 +1,60 @@
+package trysql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/blainemoser/TrySql/engines"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestParseClientError(t *testing.T) {
+	err := parseClientError(engines.ProtocolMySQL, "ERROR 1146 (42S02) at line 1: Table 'test.nope' doesn't exist")
+	if err == nil || err.Number != 1146 || err.SQLState != "42S02" || err.Message != "Table 'test.nope' doesn't exist" {
+		t.Errorf("unexpected mysql error %+v", err)
+	}
+	err = parseClientError(engines.ProtocolPostgres, "psql:<stdin>:1: ERROR:  42P01: relation \"nope\" does not exist")
+	if err == nil || err.Number != 0 || err.SQLState != "42P01" || err.Message != "relation \"nope\" does not exist" {
+		t.Errorf("unexpected postgres error %+v", err)
+	}
+	if parseClientError(engines.ProtocolMySQL, "mysql: [Warning] something") != nil {
+		t.Errorf("expected lines without an error not to parse")
+	}
+}
+
+func TestClientError(t *testing.T) {
+	engine, _ := engines.New(testConfigs(t))
+	ts := &TrySql{engine: engine}
+	err := ts.clientError(&exitError{
+		code:   1,
+		stderr: "mysql: [Warning] Using a password on the command line interface can be a security risk.\nERROR 1062 (23000) at line 1: Duplicate entry '1' for key 'PRIMARY'\n",
+	})
+	queryErr := &QueryError{}
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("expected a QueryError, got %v", err)
+	}
+	if queryErr.Number != 1062 {
+		t.Errorf("expected error number 1062, got %d", queryErr.Number)
+	}
+	other := fmt.Errorf("connection refused")
+	if ts.clientError(other) != other {
+		t.Errorf("expected errors not reported by the server to be returned unchanged")
+	}
+}
+
+func TestDriverError(t *testing.T) {
+	err := driverError(fmt.Errorf("query failed: %w", &mysql.MySQLError{Number: 1146, SQLState: [5]byte{'4', '2', 'S', '0', '2'}, Message: "Table doesn't exist"}))
+	queryErr := &QueryError{}
+	if !errors.As(err, &queryErr) || queryErr.Number != 1146 || queryErr.SQLState != "42S02" {
+		t.Errorf("unexpected mysql driver error %v", err)
+	}
+	err = driverError(&pq.Error{Code: "23505", Message: "duplicate key value"})
+	if !errors.As(err, &queryErr) || queryErr.SQLState != "23505" || queryErr.Error() != "ERROR (23505): duplicate key value" {
+		t.Errorf("unexpected postgres driver error %v", err)
+	}
+	if driverError(nil) != nil {
+		t.Errorf("expected no error to stay nil")
+	}
+}
@@ -4,10 +4,11 @@ go 1.19
 
 require (
 	github.com/blainemoser/JsonExtract v0.0.0-20220123162411-d1695ece4cb9
+	github.com/go-sql-driver/mysql v1.7.1
 	github.com/gosuri/uilive v0.0.4
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-isatty v0.0.18
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	github.com/mattn/go-isatty v0.0.18 // indirect
-	golang.org/x/sys v0.6.0 // indirect
-)
+require golang.org/x/sys v0.6.0 // indirect
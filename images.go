@@ -0,0 +1,100 @@
+package trysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/reporters"
+)
+
+// Pull policies, chosen with the pull flag
+const (
+	PullAlways       = "always"
+	PullIfNotPresent = "if-not-present"
+	PullNever        = "never"
+)
+
+// ErrImageNotCached is returned when the pull policy is never and the runtime does not hold the image
+var ErrImageNotCached = errors.New("image is not cached")
+
+// ImageStatus describes the image a sandbox was started from. Cached is whether the copy the
+// runtime already held was used, and Digest is empty for images that were never pushed
+type ImageStatus struct {
+	Image  string
+	ID     string
+	Digest string
+	Cached bool
+}
+
+func (s ImageStatus) String() string {
+	source := "pulled"
+	if s.Cached {
+		source = "cached"
+	}
+	reference := s.Digest
+	if len(reference) < 1 {
+		reference = s.ID
+	}
+	return fmt.Sprintf("%s image %s (%s)", source, s.Image, reference)
+}
+
+// ImageStatus returns how the sandbox's image was provisioned, which is unknown to attached sandboxes
+func (ts *TrySql) ImageStatus() ImageStatus {
+	return ts.imageStatus
+}
+
+// provisionImage applies the pull policy, deciding whether to pull by inspecting the runtime's copy
+// of the image. Pulling an image that turns out to be up to date still counts as using the cached one
+func (ts *TrySql) provisionImage(ctx context.Context, progress func(docker.PullProgress)) error {
+	policy := ts.Configs.GetPullPolicy()
+	cached, err := ts.runtime.InspectImage(ctx, ts.image)
+	if err != nil && !docker.IsNotFound(err) {
+		return err
+	}
+	if cached == nil && policy == PullNever {
+		return fmt.Errorf("%w: %s has not been pulled and the pull policy is never, pull it first or use --pull %s", ErrImageNotCached, ts.image, PullIfNotPresent)
+	}
+	image := cached
+	if cached == nil || policy == PullAlways {
+		err = ts.runtime.Pull(ctx, ts.image, progress)
+		if err != nil {
+			return err
+		}
+		image, err = ts.runtime.InspectImage(ctx, ts.image)
+		if err != nil {
+			return err
+		}
+	}
+	ts.imageStatus = ImageStatus{
+		Image:  ts.image,
+		ID:     image.ID,
+		Digest: image.Digest(ts.image),
+		Cached: cached != nil && cached.ID == image.ID,
+	}
+	return nil
+}
+
+// reportPull passes the pull's progress on to the reporter, and signals the provisioning phase
+// that the pull is still moving
+func (ts *TrySql) reportPull(phase string, moving chan struct{}) func(docker.PullProgress) {
+	return func(progress docker.PullProgress) {
+		select {
+		case moving <- struct{}{}:
+		default:
+		}
+		reporters.Progress(ts.reporter, phase, progress.Percent(), progress.String())
+	}
+}
+
+// provisionMessage names the provisioning phase after what the pull policy will have it do
+func provisionMessage(policy string) string {
+	switch policy {
+	case PullAlways:
+		return "pulling up to date image"
+	case PullNever:
+		return "finding cached image"
+	}
+	return "finding image, pulling it when missing"
+}
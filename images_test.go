@@ -0,0 +1,70 @@
+package trysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/reporters"
+	"github.com/blainemoser/TrySql/runtimes"
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestProvisionImage(t *testing.T) {
+	defer utils.HandelPanic(t)
+	local := &docker.ImageJSON{ID: "sha256:aaaa", RepoDigests: []string{"mysql@sha256:1111"}}
+	newer := &docker.ImageJSON{ID: "sha256:bbbb", RepoDigests: []string{"mysql@sha256:2222"}}
+	cases := []struct {
+		policy string
+		local  *docker.ImageJSON
+		remote *docker.ImageJSON
+		pulls  int
+		status ImageStatus
+	}{
+		{PullIfNotPresent, local, newer, 0, ImageStatus{"mysql:8.0", "sha256:aaaa", "sha256:1111", true}},
+		{PullIfNotPresent, nil, newer, 1, ImageStatus{"mysql:8.0", "sha256:bbbb", "sha256:2222", false}},
+		{PullAlways, local, local, 1, ImageStatus{"mysql:8.0", "sha256:aaaa", "sha256:1111", true}},
+		{PullAlways, local, newer, 1, ImageStatus{"mysql:8.0", "sha256:bbbb", "sha256:2222", false}},
+		{PullNever, local, newer, 0, ImageStatus{"mysql:8.0", "sha256:aaaa", "sha256:1111", true}},
+	}
+	for _, c := range cases {
+		rt := &imageRuntime{local: c.local, remote: c.remote}
+		ts := &TrySql{runtime: rt, image: "mysql:8.0", reporter: reporters.Silent{}, Configs: testConfigs(t, "--pull", c.policy)}
+		err := ts.provisionImage(context.Background(), ts.reportPull("pulling", make(chan struct{}, 1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rt.pulls != c.pulls || ts.ImageStatus() != c.status {
+			t.Errorf("expected %s to pull %d times and give %+v, got %d and %+v", c.policy, c.pulls, c.status, rt.pulls, ts.ImageStatus())
+		}
+	}
+	rt := &imageRuntime{remote: newer}
+	ts := &TrySql{runtime: rt, image: "mysql:8.0", reporter: reporters.Silent{}, Configs: testConfigs(t, "--pull", PullNever)}
+	err := ts.provisionImage(context.Background(), ts.reportPull("pulling", make(chan struct{}, 1)))
+	if !errors.Is(err, ErrImageNotCached) || rt.pulls > 0 {
+		t.Errorf("expected a missing image not to be pulled when the policy is never, got %v after %d pulls", err, rt.pulls)
+	}
+}
+
+// imageRuntime holds at most one image locally, replacing it with the remote one when pulled
+type imageRuntime struct {
+	runtimes.Runtime
+	local  *docker.ImageJSON
+	remote *docker.ImageJSON
+	pulls  int
+}
+
+func (i *imageRuntime) InspectImage(ctx context.Context, image string) (*docker.ImageJSON, error) {
+	if i.local == nil {
+		return nil, &docker.APIError{StatusCode: 404, Message: "No such image: " + image}
+	}
+	return i.local, nil
+}
+
+func (i *imageRuntime) Pull(ctx context.Context, image string, progress func(docker.PullProgress)) error {
+	i.pulls++
+	progress(docker.PullProgress{Layers: []docker.LayerProgress{{ID: "a1", Done: true}}})
+	i.local = i.remote
+	return nil
+}
@@ -0,0 +1,225 @@
+package trysql
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/reporters"
+)
+
+// Option configures the sandbox started by New
+type Option func(*options) error
+
+type options struct {
+	configs  *configs.Configs
+	reporter reporters.Reporter
+}
+
+// New starts a sandbox configured by the options, which are layered over the project's config
+// file and the TRYSQL_ environment variables and all checked before anything is started. Unlike
+// Initialise it reports no progress unless given WithReporter, WithProgress or a configured reporter
+func New(ctx context.Context, opts ...Option) (*TrySql, error) {
+	confs, err := configs.Load(nil)
+	if err != nil {
+		return nil, err
+	}
+	o := &options{configs: confs}
+	if !confs.IsSet("reporter") {
+		err = o.configs.Set("reporter", "none")
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, opt := range opts {
+		err = opt(o)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return start(ctx, o.configs, o.reporter)
+}
+
+// WithArgs applies command line flags, as Initialise takes them, over the options before it
+func WithArgs(args ...string) Option {
+	return func(o *options) error {
+		return o.configs.Apply(args)
+	}
+}
+
+// WithVersion sets the version tag of the engine's image, latest by default
+func WithVersion(version string) Option {
+	return set("version", version)
+}
+
+// WithPort publishes the server on the given host port
+func WithPort(port int) Option {
+	return set("port", strconv.Itoa(port))
+}
+
+// WithEngine runs the named engine: mysql, mariadb or postgres
+func WithEngine(engine string) Option {
+	return set("engine", engine)
+}
+
+// WithRuntime uses the named container runtime: docker, podman or auto
+func WithRuntime(runtime string) Option {
+	return set("runtime", runtime)
+}
+
+// WithImage runs a custom image in place of the engine's own
+func WithImage(image string) Option {
+	return set("image", image)
+}
+
+// WithImageFamily chooses between MySQL's mysql-server and official mysql images
+func WithImageFamily(family string) Option {
+	return set("image-family", family)
+}
+
+// WithReadiness sets how the server is waited for: healthcheck, ping, tcp or query
+func WithReadiness(readiness string) Option {
+	return set("readiness", readiness)
+}
+
+// WithPullPolicy sets when the image is pulled: PullAlways, PullIfNotPresent, the default, or
+// PullNever, which starts only from an image the runtime already holds
+func WithPullPolicy(policy string) Option {
+	return set("pull", policy)
+}
+
+// WithTTL has Prune remove the sandbox once it is older than ttl
+func WithTTL(ttl time.Duration) Option {
+	return set("ttl", ttl.String())
+}
+
+// WithLifetime sets what the sandbox outlives: LifetimeProcess, the default, has Prune remove it
+// once this process has exited, while LifetimeDetached keeps it until its TTL runs out
+func WithLifetime(lifetime string) Option {
+	return set("lifetime", lifetime)
+}
+
+// WithName names the sandbox's container, reusing it when it is already running
+func WithName(name string) Option {
+	return set("name", name)
+}
+
+// WithDatabases creates the named databases once the server is up
+func WithDatabases(databases ...string) Option {
+	return func(o *options) error {
+		return o.configs.Set("databases", databases...)
+	}
+}
+
+// WithSeed runs the SQL scripts at the given paths, in order, once the databases exist
+func WithSeed(scripts ...string) Option {
+	return func(o *options) error {
+		return o.configs.Set("seed", scripts...)
+	}
+}
+
+// WithPassword sets the superuser password in place of a generated one
+func WithPassword(password string) Option {
+	return set("password", password)
+}
+
+// WithPasswordFile reads the superuser password from the first line of the file at path
+func WithPasswordFile(path string) Option {
+	return set("password-file", path)
+}
+
+// WithPasswordPolicy generates passwords of the given length from the named charset: letters,
+// alphanumeric or symbols. A zero length or empty charset leaves the default in place
+func WithPasswordPolicy(length int, charset string) Option {
+	return func(o *options) error {
+		if length > 0 {
+			err := o.configs.Set("password-length", strconv.Itoa(length))
+			if err != nil {
+				return err
+			}
+		}
+		if len(charset) < 1 {
+			return nil
+		}
+		return o.configs.Set("password-charset", charset)
+	}
+}
+
+// WithUser creates an application user with a generated password and all privileges on the
+// databases, available through AppUser and AppPassword
+func WithUser(name string) Option {
+	return set("user", name)
+}
+
+// WithVariables starts the server with the given server variables, e.g. max_connections
+func WithVariables(variables map[string]string) Option {
+	return func(o *options) error {
+		values := make([]string, 0, len(variables))
+		for name, value := range variables {
+			values = append(values, name+"="+value)
+		}
+		sort.Strings(values)
+		return o.configs.Set("variable", values...)
+	}
+}
+
+// WithResources limits the container's memory, as a size such as 512m, and its CPUs. Empty or
+// zero limits are left unset
+func WithResources(memory string, cpus float64) Option {
+	return func(o *options) error {
+		if len(memory) > 0 {
+			err := o.configs.Set("memory", memory)
+			if err != nil {
+				return err
+			}
+		}
+		if cpus == 0 {
+			return nil
+		}
+		return o.configs.Set("cpus", strconv.FormatFloat(cpus, 'f', -1, 64))
+	}
+}
+
+// WithProgress reports progress in the named way: auto, spinner, plain, json or none
+func WithProgress(reporter string) Option {
+	return set("reporter", reporter)
+}
+
+// WithReporter reports progress to the caller's own Reporter
+func WithReporter(reporter reporters.Reporter) Option {
+	return func(o *options) error {
+		o.reporter = reporter
+		return nil
+	}
+}
+
+// WithTimeouts limits how long starting, waiting for the server and tearing down may each take,
+// and how long pulling may go without progress. Zero durations leave the default in place
+func WithTimeouts(pull, start, health, teardown time.Duration) Option {
+	return func(o *options) error {
+		durations := map[string]time.Duration{
+			"pull-timeout":     pull,
+			"start-timeout":    start,
+			"health-timeout":   health,
+			"teardown-timeout": teardown,
+		}
+		for flag, duration := range durations {
+			if duration == 0 {
+				continue
+			}
+			err := o.configs.Set(flag, duration.String())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func set(flag, value string) Option {
+	return func(o *options) error {
+		return o.configs.Set(flag, value)
+	}
+}
@@ -0,0 +1,62 @@
+package trysql
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blainemoser/TrySql/reporters"
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestOptions(t *testing.T) {
+	defer utils.HandelPanic(t)
+	o := &options{configs: testConfigs(t)}
+	opts := []Option{
+		WithVersion("8.0"),
+		WithPort(0),
+		WithEngine("mariadb"),
+		WithName("fixtures"),
+		WithTimeouts(time.Minute, 0, 0, 0),
+		WithArgs("--readiness", "tcp"),
+		WithReporter(reporters.Silent{}),
+	}
+	for _, opt := range opts {
+		err := opt(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	cnfs := o.configs
+	if cnfs.GetMysqlVersion() != "8.0" || cnfs.GetPort() != 0 || cnfs.GetEngine() != "mariadb" {
+		t.Errorf("expected version 8.0 of mariadb on port 0, got %s of %s on %d", cnfs.GetMysqlVersion(), cnfs.GetEngine(), cnfs.GetPort())
+	}
+	if cnfs.GetName() != "fixtures" || cnfs.GetReadiness() != "tcp" {
+		t.Errorf("expected the name and readiness to be set, got '%s' and '%s'", cnfs.GetName(), cnfs.GetReadiness())
+	}
+	if cnfs.GetPullTimeout() != time.Minute || cnfs.GetStartTimeout() != 3*time.Minute {
+		t.Errorf("expected only the pull timeout to change, got %s and %s", cnfs.GetPullTimeout(), cnfs.GetStartTimeout())
+	}
+	if o.reporter == nil {
+		t.Errorf("expected the reporter to be set")
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	defer utils.HandelPanic(t)
+	invalid := map[string][]Option{
+		"port must be a number from 0 to 65535":      {WithPort(70000)},
+		"engine must be one of mysql, mariadb":       {WithEngine("oracle")},
+		"pull-timeout must be a positive duration":   {WithTimeouts(-time.Second, 0, 0, 0)},
+		"name must start with a letter or digit":     {WithName("-sandbox")},
+		"the colour argument does not exist":         {WithArgs("--colour", "blue")},
+		"readiness must be one of healthcheck, ping": {WithVersion("8.0"), WithReadiness("eventually")},
+	}
+	for expects, opts := range invalid {
+		_, err := New(context.Background(), opts...)
+		if err == nil || !strings.Contains(err.Error(), expects) {
+			t.Errorf("expected '%s', got %v", expects, err)
+		}
+	}
+}
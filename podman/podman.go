@@ -0,0 +1,100 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/docker"
+)
+
+// Images without a registry are resolved against Docker Hub, since Podman does not assume one
+const defaultRegistry = "docker.io"
+
+// Podman is the container runtime backed by Podman's Docker compatible API service,
+// either the rootless user socket or the system socket when running as root
+type Podman struct {
+	*docker.Docker
+	Rootless bool
+	version  string
+}
+
+func New(configs *configs.Configs) (*Podman, error) {
+	d, err := docker.Connect(DefaultHost())
+	if err != nil {
+		return nil, err
+	}
+	return &Podman{
+		Docker:   d,
+		Rootless: os.Geteuid() != 0,
+	}, nil
+}
+
+// DefaultHost returns CONTAINER_HOST when it is set, otherwise the API socket for the current user
+func DefaultHost() string {
+	host := os.Getenv("CONTAINER_HOST")
+	if len(host) > 0 {
+		return host
+	}
+	if os.Geteuid() == 0 {
+		return "unix:///run/podman/podman.sock"
+	}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if len(runtimeDir) < 1 {
+		runtimeDir = "/run/user/" + strconv.Itoa(os.Geteuid())
+	}
+	return "unix://" + runtimeDir + "/podman/podman.sock"
+}
+
+func (p *Podman) Name() string {
+	return "podman"
+}
+
+// Version asks the service for its version, reported in the same form as podman -v
+func (p *Podman) Version(ctx context.Context) (string, error) {
+	if len(p.version) > 0 {
+		return p.version, nil
+	}
+	version, err := p.Client.Version(ctx)
+	if err != nil {
+		return "", fmt.Errorf(
+			"could not reach the podman service at %s (is podman.socket enabled?): %s",
+			p.Client.Host(),
+			err.Error(),
+		)
+	}
+	p.version = "podman version " + version.Version
+	if p.Rootless {
+		p.version += " (rootless)"
+	}
+	return p.version, nil
+}
+
+func (p *Podman) Pull(ctx context.Context, image string, progress func(docker.PullProgress)) error {
+	return p.Docker.Pull(ctx, qualify(image), progress)
+}
+
+func (p *Podman) InspectImage(ctx context.Context, image string) (*docker.ImageJSON, error) {
+	return p.Docker.InspectImage(ctx, qualify(image))
+}
+
+func (p *Podman) Run(ctx context.Context, name string, config *docker.ContainerConfig) (string, error) {
+	qualified := *config
+	qualified.Image = qualify(config.Image)
+	return p.Docker.Run(ctx, name, &qualified)
+}
+
+// qualify prefixes the default registry to images that do not name one
+func qualify(image string) string {
+	first := strings.SplitN(image, "/", 2)[0]
+	if strings.Contains(image, "/") && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return image
+	}
+	if !strings.Contains(image, "/") {
+		image = "library/" + image
+	}
+	return defaultRegistry + "/" + image
+}
@@ -0,0 +1,54 @@
+package podman
+
+import (
+	"os"
+	"testing"
+
+	"github.com/blainemoser/TrySql/configs"
+)
+
+func TestQualify(t *testing.T) {
+	cases := map[string]string{
+		"mysql/mysql-server:latest":        "docker.io/mysql/mysql-server:latest",
+		"mariadb:11":                       "docker.io/library/mariadb:11",
+		"quay.io/fedora/mariadb-105":       "quay.io/fedora/mariadb-105",
+		"localhost/mysql:8.0":              "localhost/mysql:8.0",
+		"registry.local:5000/mysql:latest": "registry.local:5000/mysql:latest",
+	}
+	for image, expects := range cases {
+		result := qualify(image)
+		if result != expects {
+			t.Errorf("expected '%s' to be qualified as '%s', got '%s'", image, expects, result)
+		}
+	}
+}
+
+func TestDefaultHost(t *testing.T) {
+	t.Setenv("CONTAINER_HOST", "unix:///tmp/podman.sock")
+	if DefaultHost() != "unix:///tmp/podman.sock" {
+		t.Errorf("expected CONTAINER_HOST to be honoured, got '%s'", DefaultHost())
+	}
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	expects := "unix:///run/user/1000/podman/podman.sock"
+	if os.Geteuid() == 0 {
+		expects = "unix:///run/podman/podman.sock"
+	}
+	if DefaultHost() != expects {
+		t.Errorf("expected host to be '%s', got '%s'", expects, DefaultHost())
+	}
+}
+
+func TestNew(t *testing.T) {
+	cnfs, err := configs.New([]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(cnfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "podman" {
+		t.Errorf("expected runtime name to be 'podman', got '%s'", p.Name())
+	}
+}
@@ -0,0 +1,110 @@
+package trysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrPortInUse is returned when the port the sandbox is to be published on is already taken
+var ErrPortInUse = errors.New("port is already in use")
+
+// checkPort fails early, naming whoever holds it, when the requested host port is taken. A port
+// of zero leaves the choice to the runtime, and ports on a remote engine's host cannot be checked
+func (ts *TrySql) checkPort(ctx context.Context) error {
+	if ts.hostPort == 0 || ts.Host() != "127.0.0.1" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", ":"+ts.HostPortStr())
+	if err == nil {
+		return listener.Close()
+	}
+	owner := ts.portOwner(ctx, ts.hostPort)
+	if len(owner) > 0 {
+		owner = " by " + owner
+	}
+	return fmt.Errorf("%w: %d is held%s, choose another with --port or use --port 0 for a free one", ErrPortInUse, ts.hostPort, owner)
+}
+
+// portOwner names the container publishing port, or otherwise the local process listening on it
+func (ts *TrySql) portOwner(ctx context.Context, port int) string {
+	containers, err := ts.runtime.List(ctx, false, nil)
+	if err == nil {
+		for _, container := range containers {
+			for _, published := range container.Ports {
+				if published.PublicPort == port {
+					return fmt.Sprintf("container %s (%s)", strings.TrimPrefix(strings.Join(container.Names, ","), "/"), shortID(container.ID))
+				}
+			}
+		}
+	}
+	return processOnPort(port)
+}
+
+// processOnPort finds the process listening on port through /proc, returning nothing where there
+// is no /proc or the process belongs to another user
+func processOnPort(port int) string {
+	inodes := make(map[string]bool)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		for _, inode := range listeningInodes(table, port) {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) < 1 {
+		return ""
+	}
+	processes, _ := filepath.Glob("/proc/[0-9]*")
+	for _, process := range processes {
+		descriptors, err := os.ReadDir(filepath.Join(process, "fd"))
+		if err != nil {
+			continue
+		}
+		for _, descriptor := range descriptors {
+			link, err := os.Readlink(filepath.Join(process, "fd", descriptor.Name()))
+			if err != nil || !inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+				continue
+			}
+			name, _ := os.ReadFile(filepath.Join(process, "comm"))
+			return fmt.Sprintf("%s (pid %s)", strings.TrimSpace(string(name)), filepath.Base(process))
+		}
+	}
+	return ""
+}
+
+// listeningInodes returns the socket inodes listening on port in one of the kernel's tcp tables,
+// whose local addresses are written as hex IP:port and whose state 0A is LISTEN
+func listeningInodes(table string, port int) []string {
+	contents, err := os.ReadFile(table)
+	if err != nil {
+		return nil
+	}
+	var inodes []string
+	for _, line := range strings.Split(string(contents), "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || fields[3] != "0A" {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		local, err := strconv.ParseInt(hexPort, 16, 32)
+		if err == nil && int(local) == port {
+			inodes = append(inodes, fields[9])
+		}
+	}
+	return inodes
+}
+
+// bindingPort is the host port asked of the runtime, left empty for it to choose one
+func (ts *TrySql) bindingPort() string {
+	if ts.hostPort == 0 {
+		return ""
+	}
+	return ts.HostPortStr()
+}
@@ -0,0 +1,44 @@
+package trysql
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestProcessOnPort(t *testing.T) {
+	defer utils.HandelPanic(t)
+	if _, err := os.Stat("/proc/net/tcp"); err != nil {
+		t.Skip("no /proc to look up listeners in")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+	owner := processOnPort(port)
+	if !strings.HasSuffix(owner, "(pid "+strconv.Itoa(os.Getpid())+")") {
+		t.Errorf("expected this process to hold port %d, got '%s'", port, owner)
+	}
+	listener.Close()
+	if owner = processOnPort(port); len(owner) > 0 {
+		t.Errorf("expected nobody to hold port %d once it is closed, got '%s'", port, owner)
+	}
+}
+
+func TestBindingPort(t *testing.T) {
+	defer utils.HandelPanic(t)
+	ts := &TrySql{hostPort: 0}
+	if ts.bindingPort() != "" {
+		t.Errorf("expected port 0 to leave the choice to the runtime, got '%s'", ts.bindingPort())
+	}
+	ts.hostPort = 3307
+	if ts.bindingPort() != "3307" {
+		t.Errorf("expected the requested port, got '%s'", ts.bindingPort())
+	}
+}
@@ -0,0 +1,170 @@
+package trysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/runtimes"
+	"github.com/blainemoser/TrySql/utils"
+)
+
+// Labels recording who started a sandbox and how long it may live, which Prune reads to find
+// the sandboxes that were never torn down
+const (
+	labelOwner   = "trysql.owner"
+	labelPID     = "trysql.pid"
+	labelCreated = "trysql.created"
+	labelTTL     = "trysql.ttl"
+)
+
+// Lifetimes, chosen with the lifetime flag. A sandbox with a process lifetime is pruned once the
+// process that started it exits, while a detached one is kept until its TTL runs out
+const (
+	LifetimeProcess  = "process"
+	LifetimeDetached = "detached"
+)
+
+// Pruned is a sandbox removed by Prune, with why it was and the anonymous volumes removed with it
+type Pruned struct {
+	Name    string
+	ID      string
+	Reason  string
+	Volumes []string
+}
+
+// Prune removes the sandboxes left behind by processes that exited without tearing them down,
+// and those whose TTL has run out. Flags other than the runtime's are ignored
+func Prune(args []string) ([]Pruned, error) {
+	return PruneContext(context.Background(), args)
+}
+
+// PruneContext is Prune, giving up when ctx is cancelled. Only processes on this host can be
+// checked, and sandboxes from before the labels were added are left alone. Sandboxes join the
+// runtime's default network, so they have no networks of their own to remove
+func PruneContext(ctx context.Context, args []string) ([]Pruned, error) {
+	confs, err := configs.Load(args)
+	if err != nil {
+		return nil, err
+	}
+	err = confs.Validate()
+	if err != nil {
+		return nil, err
+	}
+	rt, err := runtimes.New(confs)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := rt.List(ctx, true, map[string][]string{"label": {labelEngine}})
+	if err != nil {
+		return nil, err
+	}
+	return prune(ctx, rt, containers, time.Now())
+}
+
+// prune removes the containers that are due, carrying on past the ones that cannot be removed
+func prune(ctx context.Context, rt runtimes.Runtime, containers []docker.ContainerSummary, now time.Time) ([]Pruned, error) {
+	host := hostname()
+	pruned := []Pruned{}
+	var errs []error
+	for _, container := range containers {
+		reason := pruneReason(container.Labels, now, host)
+		if len(reason) < 1 {
+			continue
+		}
+		name := strings.TrimPrefix(strings.Join(container.Names, ","), "/")
+		err := rt.Remove(ctx, container.ID, true)
+		if err != nil && !docker.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("could not remove %s: %s", name, err.Error()))
+			continue
+		}
+		pruned = append(pruned, Pruned{
+			Name:    name,
+			ID:      shortID(container.ID),
+			Reason:  reason,
+			Volumes: anonymousVolumes(container.Mounts),
+		})
+	}
+	return pruned, utils.GetErrors(errs)
+}
+
+// pruneReason says why a sandbox with the given labels is due to be pruned, and is empty when it is not
+func pruneReason(labels map[string]string, now time.Time, host string) string {
+	created, err := time.Parse(time.RFC3339, labels[labelCreated])
+	if err != nil {
+		return ""
+	}
+	ttl, err := time.ParseDuration(labels[labelTTL])
+	if err == nil && now.After(created.Add(ttl)) {
+		return fmt.Sprintf("its ttl of %s ran out", ttl)
+	}
+	pid, err := strconv.Atoi(labels[labelPID])
+	owner := labels[labelOwner]
+	if err == nil && owner[strings.LastIndex(owner, "@")+1:] == host && !processAlive(pid) {
+		return fmt.Sprintf("its owner process %d exited", pid)
+	}
+	return ""
+}
+
+// lifecycleLabels record who is starting the sandbox, when, and how long it may live
+func (ts *TrySql) lifecycleLabels(now time.Time) map[string]string {
+	labels := map[string]string{
+		labelOwner:   owner(),
+		labelCreated: now.UTC().Format(time.RFC3339),
+	}
+	if ts.Configs.GetLifetime() != LifetimeDetached {
+		labels[labelPID] = strconv.Itoa(os.Getpid())
+	}
+	ttl := ts.Configs.GetTTL()
+	if ttl > 0 {
+		labels[labelTTL] = ttl.String()
+	}
+	return labels
+}
+
+// owner names the user and host of this process, as user@host
+func owner() string {
+	current, err := user.Current()
+	if err != nil {
+		return hostname()
+	}
+	return current.Username + "@" + hostname()
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "localhost"
+	}
+	return name
+}
+
+// processAlive reports whether a process with the given ID exists, including those of other users
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// anonymousVolumes names the volumes a container was created with from its image, which are
+// removed with it
+func anonymousVolumes(mounts []docker.MountPoint) []string {
+	var volumes []string
+	for _, mount := range mounts {
+		if mount.Type == "volume" {
+			volumes = append(volumes, mount.Name)
+		}
+	}
+	return volumes
+}
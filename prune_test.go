@@ -0,0 +1,109 @@
+package trysql
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/runtimes"
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestLifecycleLabels(t *testing.T) {
+	defer utils.HandelPanic(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 7200))
+	ts := &TrySql{Configs: testConfigs(t, "--ttl", "90m")}
+	labels := ts.lifecycleLabels(now)
+	if labels[labelCreated] != "2024-05-01T10:00:00Z" || labels[labelTTL] != "1h30m0s" || labels[labelPID] != strconv.Itoa(os.Getpid()) {
+		t.Errorf("expected the creation time, TTL and PID to be labelled, got %v", labels)
+	}
+	if !strings.HasSuffix(labels[labelOwner], hostname()) {
+		t.Errorf("expected the owner to name this host, got '%s'", labels[labelOwner])
+	}
+	ts = &TrySql{Configs: testConfigs(t, "--lifetime", LifetimeDetached)}
+	labels = ts.lifecycleLabels(now)
+	if _, ok := labels[labelPID]; ok {
+		t.Errorf("expected a detached sandbox to have no owner process, got %v", labels)
+	}
+	if _, ok := labels[labelTTL]; ok {
+		t.Errorf("expected no TTL unless one is given, got %v", labels)
+	}
+}
+
+func TestPruneReason(t *testing.T) {
+	defer utils.HandelPanic(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alive := strconv.Itoa(os.Getpid())
+	// Process IDs are limited to 2^22 on Linux, and any higher one cannot belong to a process
+	exited := strconv.Itoa(1 << 23)
+	reasons := []struct {
+		labels map[string]string
+		reason string
+	}{
+		{map[string]string{labelEngine: "mysql"}, ""},
+		{map[string]string{labelCreated: "2024-05-01T11:00:00Z", labelOwner: "ci@builder", labelPID: alive}, ""},
+		{map[string]string{labelCreated: "2024-05-01T11:00:00Z", labelOwner: "ci@builder", labelPID: exited}, "its owner process 8388608 exited"},
+		{map[string]string{labelCreated: "2024-05-01T11:00:00Z", labelOwner: "ci@laptop", labelPID: exited}, ""},
+		{map[string]string{labelCreated: "2024-05-01T11:00:00Z", labelOwner: "ci@builder", labelTTL: "2h0m0s"}, ""},
+		{map[string]string{labelCreated: "2024-05-01T09:00:00Z", labelOwner: "ci@builder", labelTTL: "2h0m0s", labelPID: alive}, "its ttl of 2h0m0s ran out"},
+	}
+	for _, r := range reasons {
+		reason := pruneReason(r.labels, now, "builder")
+		if reason != r.reason {
+			t.Errorf("expected %v to give '%s', got '%s'", r.labels, r.reason, reason)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	defer utils.HandelPanic(t)
+	now := time.Now()
+	due := map[string]string{
+		labelEngine:  "mysql",
+		labelCreated: now.Add(-time.Hour).UTC().Format(time.RFC3339),
+		labelTTL:     "1m0s",
+	}
+	containers := []docker.ContainerSummary{
+		{ID: "0123456789abcdef", Names: []string{"/expired"}, Labels: due, Mounts: []docker.MountPoint{
+			{Type: "volume", Name: "5e1f", Destination: "/var/lib/mysql"},
+			{Type: "bind", Source: "/tmp/seed", Destination: "/seed"},
+		}},
+		{ID: "fedcba9876543210", Names: []string{"/current"}, Labels: map[string]string{labelEngine: "mysql"}},
+		{ID: "gone", Names: []string{"/gone"}, Labels: due},
+		{ID: "stuck", Names: []string{"/stuck"}, Labels: due},
+	}
+	rt := &removingRuntime{}
+	pruned, err := prune(context.Background(), rt, containers, now)
+	if err == nil || err.Error() != "could not remove stuck: removal of container stuck is already in progress" {
+		t.Errorf("expected the failed removal to be reported, got %v", err)
+	}
+	if strings.Join(rt.removed, ",") != "0123456789abcdef,gone,stuck" {
+		t.Errorf("expected only the expired sandboxes to be removed, got %v", rt.removed)
+	}
+	if len(pruned) != 2 || pruned[0].Name != "expired" || pruned[0].ID != "0123456789ab" || strings.Join(pruned[0].Volumes, ",") != "5e1f" || pruned[1].Name != "gone" {
+		t.Errorf("expected the expired and already removed sandboxes with their volumes, got %+v", pruned)
+	}
+}
+
+// removingRuntime records the containers it is asked to remove, of which gone has already been
+// removed and stuck cannot be
+type removingRuntime struct {
+	runtimes.Runtime
+	removed []string
+}
+
+func (r *removingRuntime) Remove(ctx context.Context, id string, force bool) error {
+	r.removed = append(r.removed, id)
+	switch id {
+	case "gone":
+		return &docker.APIError{StatusCode: 404, Message: "No such container: gone"}
+	case "stuck":
+		return errors.New("removal of container stuck is already in progress")
+	}
+	return nil
+}
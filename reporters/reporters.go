@@ -0,0 +1,321 @@
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/utils"
+	"github.com/gosuri/uilive"
+	"github.com/mattn/go-isatty"
+)
+
+// Reporter is told about the phases of a sandbox's lifecycle as they happen. Phases run one
+// after the other, so a phase is always done before the next one starts
+type Reporter interface {
+	Start(phase string)
+	Done(phase string, err error)
+	Info(message string)
+}
+
+// ProgressReporter is a Reporter that is also told how far a running phase has got, for the
+// phases that can tell. Detail's first line summarises the progress and any further lines
+// break it down, e.g. by image layer
+type ProgressReporter interface {
+	Reporter
+	Progress(phase string, percent int, detail string)
+}
+
+// Progress passes a phase's progress on to reporter when it is a ProgressReporter. Reporters
+// that are not only see the phase start and end
+func Progress(reporter Reporter, phase string, percent int, detail string) {
+	progress, ok := reporter.(ProgressReporter)
+	if ok {
+		progress.Progress(phase, percent, detail)
+	}
+}
+
+// New returns the reporter named in the configs, writing to out. Auto spins on a terminal and
+// writes a line per phase anywhere else, such as CI logs and go test output
+func New(configs *configs.Configs, out io.Writer) (Reporter, error) {
+	name := strings.ToLower(configs.GetReporter())
+	switch name {
+	case "spinner":
+		return NewSpinner(out), nil
+	case "plain":
+		return NewPlain(out), nil
+	case "json":
+		return NewJSON(out), nil
+	case "none":
+		return Silent{}, nil
+	case "auto":
+		if IsTerminal(out) {
+			return NewSpinner(out), nil
+		}
+		return NewPlain(out), nil
+	}
+	return nil, fmt.Errorf("unknown reporter '%s', expected auto, spinner, plain, json or none", name)
+}
+
+// IsTerminal reports whether out is a terminal that can be redrawn in place
+func IsTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(file.Fd()) && os.Getenv("TERM") != "dumb"
+}
+
+// Spinner redraws the running phase's line in place until it is done
+type Spinner struct {
+	out    io.Writer
+	mu     sync.Mutex
+	stop   chan error
+	done   chan struct{}
+	detail progressDetail
+}
+
+func NewSpinner(out io.Writer) *Spinner {
+	return &Spinner{out: out}
+}
+
+func (s *Spinner) Start(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop = make(chan error, 1)
+	s.done = make(chan struct{})
+	s.detail.set("")
+	go s.spin(phase, s.stop, s.done)
+}
+
+func (s *Spinner) Done(phase string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return
+	}
+	s.stop <- err
+	<-s.done
+	s.stop = nil
+}
+
+func (s *Spinner) Info(message string) {
+	fmt.Fprintln(s.out, message)
+}
+
+// Progress shows the detail under the phase's line, from its next redraw on
+func (s *Spinner) Progress(phase string, percent int, detail string) {
+	s.detail.set(fmt.Sprintf("%d%% %s", percent, detail))
+}
+
+func (s *Spinner) spin(phase string, stop chan error, done chan struct{}) {
+	defer close(done)
+	writer := uilive.New()
+	writer.Out = s.out
+	writer.Start()
+	defer writer.Stop()
+	updating := []string{"|", "/", "-", "\\"}
+	uIndex := 0
+	tick := time.NewTicker(time.Millisecond * 200)
+	defer tick.Stop()
+	for {
+		fmt.Fprintf(writer, phase+" %s\n", updating[uIndex])
+		detail := s.detail.get()
+		if len(detail) > 0 {
+			fmt.Fprintln(writer, "  "+strings.ReplaceAll(detail, "\n", "\n  "))
+		}
+		select {
+		case err := <-stop:
+			if err != nil {
+				fmt.Fprintf(writer, "%s failed: %s\n", phase, err.Error())
+				return
+			}
+			fmt.Fprintf(writer, phase+" %s\n", "done")
+			return
+		case <-tick.C:
+			uIndex = (uIndex + 1) % len(updating)
+		}
+	}
+}
+
+// progressDetail is the latest progress of the spinner's phase, set while it spins
+type progressDetail struct {
+	mu     sync.Mutex
+	detail string
+}
+
+func (p *progressDetail) set(detail string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.detail = detail
+}
+
+func (p *progressDetail) get() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.detail
+}
+
+// Plain writes one line as each phase starts and another as it ends, and never redraws. Progress
+// is written as a line of its own every ten percent
+type Plain struct {
+	out      io.Writer
+	mu       sync.Mutex
+	reported int
+}
+
+func NewPlain(out io.Writer) *Plain {
+	return &Plain{out: out}
+}
+
+func (p *Plain) Start(phase string) {
+	p.mu.Lock()
+	p.reported = 0
+	p.mu.Unlock()
+	fmt.Fprintln(p.out, phase)
+}
+
+func (p *Plain) Done(phase string, err error) {
+	if err != nil {
+		fmt.Fprintf(p.out, "%s failed: %s\n", phase, err.Error())
+		return
+	}
+	fmt.Fprintf(p.out, "%s done\n", phase)
+}
+
+func (p *Plain) Info(message string) {
+	fmt.Fprintln(p.out, message)
+}
+
+// Progress writes the first line of the detail once the phase is another ten percent along
+func (p *Plain) Progress(phase string, percent int, detail string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if percent/10 <= p.reported/10 {
+		return
+	}
+	p.reported = percent
+	summary, _, _ := strings.Cut(detail, "\n")
+	fmt.Fprintf(p.out, "%s %d%% %s\n", phase, percent, summary)
+}
+
+// Event is a line written by the JSON reporter. Elapsed is set on the events ending a phase and
+// Percent on its progress events
+type Event struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Phase   string    `json:"phase,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Elapsed float64   `json:"elapsed_seconds,omitempty"`
+	Percent *int      `json:"percent,omitempty"`
+}
+
+// JSON writes an Event per line, for tools that follow the sandbox's progress. A phase's
+// progress is written each time its percentage changes
+type JSON struct {
+	out      io.Writer
+	mu       sync.Mutex
+	started  map[string]time.Time
+	reported map[string]int
+}
+
+func NewJSON(out io.Writer) *JSON {
+	return &JSON{
+		out:      out,
+		started:  make(map[string]time.Time),
+		reported: make(map[string]int),
+	}
+}
+
+func (j *JSON) Start(phase string) {
+	j.mu.Lock()
+	j.started[phase] = time.Now()
+	j.mu.Unlock()
+	j.write(&Event{Event: "start", Phase: phase})
+}
+
+func (j *JSON) Done(phase string, err error) {
+	j.mu.Lock()
+	started, ok := j.started[phase]
+	delete(j.started, phase)
+	delete(j.reported, phase)
+	j.mu.Unlock()
+	event := &Event{Event: "done", Phase: phase}
+	if ok {
+		event.Elapsed = time.Since(started).Seconds()
+	}
+	if err != nil {
+		event.Event = "failed"
+		event.Error = err.Error()
+	}
+	j.write(event)
+}
+
+func (j *JSON) Info(message string) {
+	j.write(&Event{Event: "info", Message: message})
+}
+
+func (j *JSON) Progress(phase string, percent int, detail string) {
+	j.mu.Lock()
+	reported, ok := j.reported[phase]
+	j.reported[phase] = percent
+	j.mu.Unlock()
+	if ok && reported == percent {
+		return
+	}
+	j.write(&Event{Event: "progress", Phase: phase, Message: detail, Percent: &percent})
+}
+
+func (j *JSON) write(event *Event) {
+	event.Time = time.Now().UTC()
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.out.Write(append(line, '\n'))
+}
+
+// Redacting hides secrets from the messages and errors passed on to another reporter. The
+// secrets are asked for on every call, since they may be learnt after the reporter is made
+type Redacting struct {
+	reporter Reporter
+	secrets  func() []string
+}
+
+// Redact wraps reporter so that none of the secrets reach it
+func Redact(reporter Reporter, secrets func() []string) *Redacting {
+	return &Redacting{reporter: reporter, secrets: secrets}
+}
+
+func (r *Redacting) Start(phase string) {
+	r.reporter.Start(utils.Redact(phase, r.secrets()...))
+}
+
+func (r *Redacting) Done(phase string, err error) {
+	r.reporter.Done(utils.Redact(phase, r.secrets()...), utils.RedactError(err, r.secrets()...))
+}
+
+func (r *Redacting) Info(message string) {
+	r.reporter.Info(utils.Redact(message, r.secrets()...))
+}
+
+func (r *Redacting) Progress(phase string, percent int, detail string) {
+	Progress(r.reporter, utils.Redact(phase, r.secrets()...), percent, utils.Redact(detail, r.secrets()...))
+}
+
+// Silent reports nothing, for library use where the caller handles errors itself
+type Silent struct{}
+
+func (Silent) Start(phase string) {}
+
+func (Silent) Done(phase string, err error) {}
+
+func (Silent) Info(message string) {}
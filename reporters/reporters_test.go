@@ -0,0 +1,143 @@
+package reporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/utils"
+)
+
+func TestNew(t *testing.T) {
+	defer utils.HandelPanic(t)
+	expects := map[string]string{
+		"":        "*reporters.Plain",
+		"plain":   "*reporters.Plain",
+		"spinner": "*reporters.Spinner",
+		"json":    "*reporters.JSON",
+		"none":    "reporters.Silent",
+	}
+	for name, kind := range expects {
+		args := []string{}
+		if len(name) > 0 {
+			args = []string{"--reporter", name}
+		}
+		reporter, err := New(testConfigs(t, args...), &bytes.Buffer{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprintf("%T", reporter) != kind {
+			t.Errorf("expected '%s' to give a %s, got %s", name, kind, fmt.Sprintf("%T", reporter))
+		}
+	}
+	_, err := New(testConfigs(t, "--reporter", "loud"), &bytes.Buffer{})
+	if err == nil {
+		t.Errorf("expected an unknown reporter to be refused")
+	}
+}
+
+func TestPlain(t *testing.T) {
+	defer utils.HandelPanic(t)
+	out := &bytes.Buffer{}
+	reporter := NewPlain(out)
+	reporter.Start("pulling image")
+	reporter.Done("pulling image", nil)
+	reporter.Start("starting container")
+	reporter.Done("starting container", errors.New("port in use"))
+	expects := "pulling image\npulling image done\nstarting container\nstarting container failed: port in use\n"
+	if out.String() != expects {
+		t.Errorf("expected '%s', got '%s'", expects, out.String())
+	}
+}
+
+func TestRedacting(t *testing.T) {
+	defer utils.HandelPanic(t)
+	out := &bytes.Buffer{}
+	secrets := []string{}
+	reporter := Redact(NewPlain(out), func() []string { return secrets })
+	secrets = append(secrets, "s3cret")
+	reporter.Info("password is s3cret")
+	reporter.Start("creating user")
+	reporter.Done("creating user", errors.New("ERROR 1396: IDENTIFIED BY 's3cret'"))
+	expects := "password is ********\ncreating user\ncreating user failed: ERROR 1396: IDENTIFIED BY '********'\n"
+	if out.String() != expects {
+		t.Errorf("expected '%s', got '%s'", expects, out.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	defer utils.HandelPanic(t)
+	out := &bytes.Buffer{}
+	reporter := NewJSON(out)
+	reporter.Info("found docker")
+	reporter.Start("pulling image")
+	reporter.Done("pulling image", errors.New("denied"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(lines))
+	}
+	event := &Event{}
+	err := json.Unmarshal([]byte(lines[2]), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != "failed" || event.Phase != "pulling image" || event.Error != "denied" {
+		t.Errorf("expected a failed event for the phase, got %s", lines[2])
+	}
+}
+
+func TestSpinner(t *testing.T) {
+	defer utils.HandelPanic(t)
+	out := &bytes.Buffer{}
+	reporter := NewSpinner(out)
+	reporter.Start("waiting for container")
+	reporter.Progress("waiting for container", 40, "2 of 5 layers\na1  done")
+	time.Sleep(300 * time.Millisecond)
+	reporter.Done("waiting for container", nil)
+	if !strings.Contains(out.String(), "  40% 2 of 5 layers\n  a1  done") {
+		t.Errorf("expected the progress under the phase, got '%s'", out.String())
+	}
+	if !strings.Contains(out.String(), "waiting for container done") {
+		t.Errorf("expected the phase to be marked done, got '%s'", out.String())
+	}
+	if IsTerminal(out) {
+		t.Errorf("expected a buffer not to be a terminal")
+	}
+}
+
+func TestProgress(t *testing.T) {
+	defer utils.HandelPanic(t)
+	out := &bytes.Buffer{}
+	plain := NewPlain(out)
+	plain.Start("pulling image")
+	for _, percent := range []int{0, 4, 12, 15, 31, 100} {
+		Progress(plain, "pulling image", percent, fmt.Sprintf("%d bytes\nlayer a1", percent))
+	}
+	expects := "pulling image\npulling image 12% 12 bytes\npulling image 31% 31 bytes\npulling image 100% 100 bytes\n"
+	if out.String() != expects {
+		t.Errorf("expected progress every ten percent, '%s', got '%s'", expects, out.String())
+	}
+	out.Reset()
+	reporter := NewJSON(out)
+	for _, percent := range []int{0, 0, 50} {
+		Progress(Redact(reporter, func() []string { return []string{"s3cret"} }), "pulling image", percent, "s3cret")
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"percent":0`) || !strings.Contains(lines[1], `"message":"********","percent":50`) {
+		t.Errorf("expected a redacted event each time the percentage changes, got %v", lines)
+	}
+	Progress(Silent{}, "pulling image", 50, "ignored")
+}
+
+func testConfigs(t *testing.T, args ...string) *configs.Configs {
+	cnfs, err := configs.New(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cnfs
+}
@@ -0,0 +1,206 @@
+package trysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Statements starting with these keywords return rows; anything else is executed for its result
+var rowKeywords = map[string]bool{
+	"SELECT":   true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"EXPLAIN":  true,
+	"WITH":     true,
+	"VALUES":   true,
+	"TABLE":    true,
+	"CALL":     true,
+}
+
+// Result is the structured outcome of a statement run with QueryRows. Rows hold typed values:
+// int64 or uint64 for integers, float64 for floating point columns, time.Time for dates and
+// times, bool for booleans, nil for NULL and string for everything else, decimals included
+type Result struct {
+	Columns      []string
+	Rows         [][]interface{}
+	RowsAffected int64
+	LastInsertID int64
+}
+
+// QueryRows runs a statement over the engine's wire protocol, with optional placeholder args,
+// returning errors reported by the server as a *QueryError. RowsAffected and LastInsertID are
+// set for statements that do not return rows; LastInsertID is always zero for postgres, which
+// has no such concept, use RETURNING instead
+func (ts *TrySql) QueryRows(query string, args ...interface{}) (*Result, error) {
+	return ts.QueryRowsContext(context.Background(), query, args...)
+}
+
+// QueryRowsContext is QueryRows, abandoning the statement when the context ends
+func (ts *TrySql) QueryRowsContext(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+	db, err := ts.database()
+	if err != nil {
+		return nil, err
+	}
+	return runStatement(ctx, db, query, args...)
+}
+
+// Column returns the index of the named column, or -1 when there is no such column
+func (r *Result) Column(name string) int {
+	for i, column := range r.Columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Value returns the value of the named column in the given row
+func (r *Result) Value(row int, column string) (interface{}, error) {
+	if row < 0 || row >= len(r.Rows) {
+		return nil, fmt.Errorf("row %d is out of range, the result has %d rows", row, len(r.Rows))
+	}
+	index := r.Column(column)
+	if index < 0 {
+		return nil, fmt.Errorf("the result has no column '%s'", column)
+	}
+	return r.Rows[row][index], nil
+}
+
+// querier is satisfied by connection pools, single connections and transactions alike
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func runStatement(ctx context.Context, q querier, query string, args ...interface{}) (*Result, error) {
+	if !returnsRows(query) {
+		result, err := execResult(q.ExecContext(ctx, query, args...))
+		return result, driverError(err)
+	}
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, driverError(err)
+	}
+	defer rows.Close()
+	result, err := scanRows(rows)
+	return result, driverError(err)
+}
+
+func execResult(result sql.Result, err error) (*Result, error) {
+	if err != nil {
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	// Drivers without insert IDs return an error here, which only means there is no ID to report
+	lastID, _ := result.LastInsertId()
+	return &Result{
+		Columns:      []string{},
+		Rows:         [][]interface{}{},
+		RowsAffected: affected,
+		LastInsertID: lastID,
+	}, nil
+}
+
+func scanRows(rows *sql.Rows) (*Result, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Columns: columns,
+		Rows:    make([][]interface{}, 0),
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return nil, err
+		}
+		for i := range values {
+			values[i] = typedValue(values[i], types[i].DatabaseTypeName())
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// typedValue converts the raw bytes drivers return for text protocol results into Go values
+func typedValue(value interface{}, typeName string) interface{} {
+	raw, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+	text := string(raw)
+	typeName = strings.ToUpper(typeName)
+	switch {
+	case strings.Contains(typeName, "INT") || typeName == "YEAR":
+		if number, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return number
+		}
+		if number, err := strconv.ParseUint(text, 10, 64); err == nil {
+			return number
+		}
+	case strings.Contains(typeName, "FLOAT") || strings.Contains(typeName, "DOUBLE") || typeName == "REAL":
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number
+		}
+	case typeName == "BOOL":
+		if boolean, err := strconv.ParseBool(text); err == nil {
+			return boolean
+		}
+	}
+	return text
+}
+
+// returnsRows decides by the statement's first keyword, after any comments or parentheses,
+// whether it is a query. Postgres statements with a RETURNING clause are queries too
+func returnsRows(query string) bool {
+	query = strings.TrimSpace(query)
+	for {
+		switch {
+		case strings.HasPrefix(query, "("):
+			query = strings.TrimSpace(query[1:])
+		case strings.HasPrefix(query, "--") || strings.HasPrefix(query, "#"):
+			end := strings.Index(query, "\n")
+			if end < 0 {
+				return false
+			}
+			query = strings.TrimSpace(query[end:])
+		case strings.HasPrefix(query, "/*"):
+			end := strings.Index(query, "*/")
+			if end < 0 {
+				return false
+			}
+			query = strings.TrimSpace(query[end+2:])
+		default:
+			fields := strings.Fields(strings.ToUpper(query))
+			if len(fields) < 1 {
+				return false
+			}
+			if rowKeywords[strings.TrimRight(fields[0], ";")] {
+				return true
+			}
+			for _, field := range fields {
+				if field == "RETURNING" {
+					return true
+				}
+			}
+			return false
+		}
+	}
+}
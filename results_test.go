@@ -0,0 +1,71 @@
+package trysql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReturnsRows(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT 1":                              true,
+		"  show variables":                      true,
+		"(SELECT 1) UNION (SELECT 2)":           true,
+		"/* hint */ select 1":                   true,
+		"-- comment\nSELECT 1":                  true,
+		"WITH t AS (SELECT 1) SELECT * FROM t":  true,
+		"INSERT INTO t VALUES (1) RETURNING id": true,
+		"INSERT INTO t VALUES (1)":              false,
+		"UPDATE t SET selected = 1":             false,
+		"CREATE TABLE t (id INT)":               false,
+		"-- only a comment":                     false,
+	}
+	for query, expects := range cases {
+		if returnsRows(query) != expects {
+			t.Errorf("expected returnsRows(%q) to be %v", query, expects)
+		}
+	}
+}
+
+func TestTypedValue(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		value    interface{}
+		typeName string
+		expects  interface{}
+	}{
+		{[]byte("42"), "INT", int64(42)},
+		{[]byte("18446744073709551615"), "UNSIGNED BIGINT", uint64(18446744073709551615)},
+		{[]byte("1.5"), "DOUBLE", 1.5},
+		{[]byte("1.50"), "DECIMAL", "1.50"},
+		{[]byte("t"), "BOOL", true},
+		{[]byte("a | b"), "VARCHAR", "a | b"},
+		{nil, "VARCHAR", nil},
+		{now, "DATETIME", now},
+		{int64(7), "INT8", int64(7)},
+	}
+	for _, c := range cases {
+		result := typedValue(c.value, c.typeName)
+		if result != c.expects {
+			t.Errorf("expected %v (%s) to convert to %v (%T), got %v (%T)", c.value, c.typeName, c.expects, c.expects, result, result)
+		}
+	}
+}
+
+func TestResultValue(t *testing.T) {
+	result := &Result{
+		Columns: []string{"id", "label"},
+		Rows:    [][]interface{}{{int64(1), "first"}},
+	}
+	value, err := result.Value(0, "label")
+	if err != nil || value != "first" {
+		t.Errorf("expected 'first', got '%v' (%v)", value, err)
+	}
+	_, err = result.Value(1, "label")
+	if err == nil {
+		t.Errorf("expected an error for a row out of range")
+	}
+	_, err = result.Value(0, "missing")
+	if err == nil {
+		t.Errorf("expected an error for an unknown column")
+	}
+}
@@ -0,0 +1,80 @@
+package runtimes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/podman"
+)
+
+// Runtime is what a sandbox needs from a container engine. The engine's types are those of
+// the Docker Engine API, which every supported runtime speaks
+type Runtime interface {
+	Name() string
+	Host() string
+	Version(ctx context.Context) (string, error)
+	Pull(ctx context.Context, image string, progress func(docker.PullProgress)) error
+	InspectImage(ctx context.Context, image string) (*docker.ImageJSON, error)
+	Run(ctx context.Context, name string, config *docker.ContainerConfig) (string, error)
+	List(ctx context.Context, all bool, filters map[string][]string) ([]docker.ContainerSummary, error)
+	Inspect(ctx context.Context, id string) (*docker.ContainerJSON, error)
+	InspectRaw(ctx context.Context, id string) ([]byte, error)
+	Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (*docker.ExecResult, error)
+	Logs(ctx context.Context, id string, tail int) (string, error)
+	Stop(ctx context.Context, id string, timeout int) error
+	Remove(ctx context.Context, id string, force bool) error
+}
+
+// New returns the runtime named in the configs, detecting one when it is set to auto
+func New(configs *configs.Configs) (Runtime, error) {
+	name := strings.ToLower(configs.GetRuntime())
+	switch name {
+	case "docker":
+		return newDocker(configs)
+	case "podman":
+		return newPodman(configs)
+	case "auto":
+		return detect(configs)
+	}
+	return nil, fmt.Errorf("unknown container runtime '%s', expected docker, podman or auto", name)
+}
+
+// detect prefers an explicitly configured docker host, then whichever engine socket exists
+func detect(configs *configs.Configs) (Runtime, error) {
+	if len(os.Getenv("DOCKER_HOST")) > 0 || socketExists(docker.DefaultHost()) {
+		return newDocker(configs)
+	}
+	if socketExists(podman.DefaultHost()) {
+		return newPodman(configs)
+	}
+	return newDocker(configs)
+}
+
+func newDocker(configs *configs.Configs) (Runtime, error) {
+	d, err := docker.New(configs)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func newPodman(configs *configs.Configs) (Runtime, error) {
+	p, err := podman.New(configs)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func socketExists(host string) bool {
+	if !strings.HasPrefix(host, "unix://") {
+		return false
+	}
+	_, err := os.Stat(strings.TrimPrefix(host, "unix://"))
+	return err == nil
+}
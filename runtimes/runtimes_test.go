@@ -0,0 +1,56 @@
+package runtimes
+
+import (
+	"testing"
+
+	"github.com/blainemoser/TrySql/configs"
+)
+
+func TestNew(t *testing.T) {
+	expects := map[string]string{
+		"docker": "docker",
+		"podman": "podman",
+		"Podman": "podman",
+	}
+	for flag, name := range expects {
+		cnfs, err := configs.New([]string{"--runtime", flag})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rt, err := New(cnfs)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if rt.Name() != name {
+			t.Errorf("expected runtime '%s' for flag '%s', got '%s'", name, flag, rt.Name())
+		}
+	}
+}
+
+func TestNewUnknown(t *testing.T) {
+	cnfs, err := configs.New([]string{"--runtime", "lxc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = New(cnfs)
+	exp := "unknown container runtime 'lxc', expected docker, podman or auto"
+	if err == nil || err.Error() != exp {
+		t.Errorf("expected error '%s', got %v", exp, err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	cnfs, err := configs.New([]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, err := New(cnfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Name() != "docker" {
+		t.Errorf("expected DOCKER_HOST to select docker, got '%s'", rt.Name())
+	}
+}
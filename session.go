@@ -0,0 +1,114 @@
+package trysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// Session runs statements in order on one long-lived connection, so that transactions, user
+// variables, temporary tables and the current database carry over from one call to the next
+type Session struct {
+	conn *sql.Conn
+	tx   *sql.Tx
+	mu   sync.Mutex
+}
+
+// Session opens a connection to the sandbox for the caller's exclusive use; close it when done
+func (ts *TrySql) Session() (*Session, error) {
+	db, err := ts.database()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, driverError(err)
+	}
+	return &Session{conn: conn}, nil
+}
+
+// Query runs a statement on the session's connection, inside the open transaction if there is one.
+// Results and errors are those of QueryRows
+func (s *Session) Query(query string, args ...interface{}) (*Result, error) {
+	return s.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext is Query, abandoning the statement when the context ends
+func (s *Session) QueryContext(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil, errors.New("session is closed")
+	}
+	if s.tx != nil {
+		return runStatement(ctx, s.tx, query, args...)
+	}
+	return runStatement(ctx, s.conn, query, args...)
+}
+
+// Begin starts a transaction, which the session's statements then run in until Commit or Rollback
+func (s *Session) Begin() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return errors.New("session is closed")
+	}
+	if s.tx != nil {
+		return errors.New("a transaction is already open")
+	}
+	tx, err := s.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return driverError(err)
+	}
+	s.tx = tx
+	return nil
+}
+
+// InTransaction reports whether a transaction is open
+func (s *Session) InTransaction() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tx != nil
+}
+
+// Commit commits the open transaction
+func (s *Session) Commit() error {
+	return s.endTransaction(func(tx *sql.Tx) error {
+		return tx.Commit()
+	})
+}
+
+// Rollback rolls back the open transaction
+func (s *Session) Rollback() error {
+	return s.endTransaction(func(tx *sql.Tx) error {
+		return tx.Rollback()
+	})
+}
+
+// Close rolls back any open transaction and releases the connection
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	if s.tx != nil {
+		s.tx.Rollback()
+		s.tx = nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *Session) endTransaction(end func(*sql.Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tx == nil {
+		return errors.New("no transaction is open")
+	}
+	tx := s.tx
+	s.tx = nil
+	return driverError(end(tx))
+}
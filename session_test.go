@@ -0,0 +1,22 @@
+package trysql
+
+import "testing"
+
+func TestClosedSession(t *testing.T) {
+	s := &Session{}
+	_, err := s.Query("SELECT 1")
+	if err == nil || err.Error() != "session is closed" {
+		t.Errorf("expected 'session is closed', got %v", err)
+	}
+	err = s.Begin()
+	if err == nil || err.Error() != "session is closed" {
+		t.Errorf("expected 'session is closed', got %v", err)
+	}
+	err = s.Commit()
+	if err == nil || err.Error() != "no transaction is open" {
+		t.Errorf("expected 'no transaction is open', got %v", err)
+	}
+	if s.Close() != nil {
+		t.Errorf("expected closing a closed session to be a no-op")
+	}
+}
@@ -1,9 +1,12 @@
 package trysql
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -13,106 +16,264 @@ import (
 	jsonextract "github.com/blainemoser/JsonExtract"
 	"github.com/blainemoser/TrySql/configs"
 	"github.com/blainemoser/TrySql/docker"
-	"github.com/gosuri/uilive"
+	"github.com/blainemoser/TrySql/engines"
+	"github.com/blainemoser/TrySql/reporters"
+	"github.com/blainemoser/TrySql/runtimes"
+	"github.com/blainemoser/TrySql/utils"
 )
 
 var Testing bool
 
-// This is a warning generated by MySql when a password is passed to a command line invocation.
-// The warning is not relevant here since it's a test environment
-const securityWarning = "[Warning] Using a password on the command line interface can be a security risk."
+// Every container is prefixed with this so that sandboxes are recognisable in docker ps
+const containerPrefix = "TrySql"
+
+// probeTimeout limits each readiness probe that connects from the host
+const probeTimeout = 5 * time.Second
+
+// startupLogLines is how much of the server's log is kept with the error when it stops while starting
+const startupLogLines = 20
 
 type TrySql struct {
-	docker     *docker.Docker
-	image      string
-	hash       string
-	ReadyState int
-	Configs    *configs.Configs
-	Details    *jsonextract.JSONExtract
+	runtime      runtimes.Runtime
+	engine       engines.Engine
+	version      string
+	password     string
+	user         string
+	userPassword string
+	hostPort     int
+	image        string
+	imageStatus  ImageStatus
+	name         string
+	hash         string
+	adopted      bool
+	db           *sql.DB
+	dbMu         sync.Mutex
+	reporter     reporters.Reporter
+	ReadyState   int
+	Configs      *configs.Configs
+	Details      *jsonextract.JSONExtract
 }
 
 func Initialise(args []string) (*TrySql, error) {
+	return InitialiseContext(context.Background(), args)
+}
+
+// InitialiseContext starts a sandbox, stopping at the first phase to fail, time out or be
+// cancelled through ctx. A container that was created before then is removed again. The args
+// are layered over the project's config file and the TRYSQL_ environment variables
+func InitialiseContext(ctx context.Context, args []string) (*TrySql, error) {
 	var err error
 	if len(args) < 1 {
 		args = getArgs()
 	}
-	confs, err := configs.New(args)
+	confs, err := configs.Load(args)
 	if err != nil {
 		return nil, err
 	}
-	ts, err := generate(confs)
+	return start(ctx, confs, nil)
+}
+
+// start validates the configs before starting the sandbox they describe. Without a reporter
+// one is chosen by the configs
+func start(ctx context.Context, confs *configs.Configs, reporter reporters.Reporter) (*TrySql, error) {
+	err := confs.Validate()
 	if err != nil {
 		return nil, err
 	}
-	fmt.Println("found " + string(ts.DockerVersion()))
-	err = ts.provision()
+	ts, err := generate(ctx, confs, reporter)
 	if err != nil {
 		return nil, err
 	}
-	err = ts.run()
+	err = ts.launch(ctx)
 	if err != nil {
-		return nil, err
+		return nil, ts.redact(err)
 	}
-	err = ts.waitForHealthy()
+	return ts, nil
+}
+
+// launch pulls, runs and waits for the sandbox, then creates its databases
+func (ts *TrySql) launch(ctx context.Context) error {
+	ts.reporter.Info("found " + ts.DockerVersion())
+	err := ts.provision(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return ts, nil
+	err = ts.run(ctx)
+	if err != nil {
+		return ts.abandon(err)
+	}
+	err = ts.waitForHealthy(ctx)
+	if err != nil {
+		return ts.abandon(err)
+	}
+	err = ts.prepare(ctx)
+	if err != nil {
+		return ts.abandon(err)
+	}
+	return nil
 }
 
-func generate(configs *configs.Configs) (*TrySql, error) {
-	d, err := docker.New(configs)
+func generate(ctx context.Context, configs *configs.Configs, reporter reporters.Reporter) (*TrySql, error) {
+	rt, err := runtimes.New(configs)
 	if err != nil {
 		return nil, err
 	}
-	ts := &TrySql{
-		docker:  d,
-		image:   "mysql/mysql-server:" + configs.GetMysqlVersion(),
-		Configs: configs,
+	engine, err := engines.New(configs)
+	if err != nil {
+		return nil, err
 	}
-	err = ts.initDocker()
+	if reporter == nil {
+		reporter, err = reporters.New(configs, os.Stdout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	password, userPassword, err := credentials(configs)
 	if err != nil {
 		return nil, err
 	}
+	name := configs.GetName()
+	if len(name) < 1 {
+		name = utils.UniqueName(containerPrefix)
+	}
+	ts := &TrySql{
+		runtime:      rt,
+		engine:       engine,
+		password:     password,
+		user:         configs.GetUser(),
+		userPassword: userPassword,
+		hostPort:     configs.GetPort(),
+		image:        engine.Image(),
+		name:         name,
+		reporter:     reporter,
+		Configs:      configs,
+	}
+	ts.reporter = reporters.Redact(reporter, ts.secrets)
+	err = ts.initRuntime(ctx)
+	if err != nil {
+		return nil, ts.redact(err)
+	}
 	return ts, nil
 }
 
+// DockerVersion returns the version reported by the container runtime, Docker or otherwise
 func (ts *TrySql) DockerVersion() string {
-	return ts.docker.Version
+	return ts.version
+}
+
+// Runtime returns the name of the container runtime hosting the sandbox
+func (ts *TrySql) Runtime() string {
+	return ts.runtime.Name()
+}
+
+// Name returns the unique name given to this sandbox's container
+func (ts *TrySql) Name() string {
+	return ts.name
+}
+
+// ContainerID returns the ID of this sandbox's container, or its name when the container is yet to be created
+func (ts *TrySql) ContainerID() string {
+	if len(ts.hash) > 0 {
+		return ts.hash
+	}
+	return ts.name
+}
+
+// Engine returns the name of the database engine running in the sandbox
+func (ts *TrySql) Engine() string {
+	return ts.engine.Name()
+}
+
+// ConnectCommand returns the engine's client command for connecting to the sandbox from the host
+func (ts *TrySql) ConnectCommand() string {
+	return ts.engine.ConnectCommand(ts.endpoint())
 }
 
 func (ts *TrySql) MySQLCommand() string {
-	return fmt.Sprintf(
-		"mysql -uroot -p%s -h127.0.0.1 -P%s",
-		ts.Password(),
-		ts.HostPortStr(),
-	)
+	return ts.ConnectCommand()
 }
 
+// Query runs a query with the engine's client, returning its output with rows joined by " | ".
+// Errors reported by the server are returned as a *QueryError; with report set, the error
+// message is also appended to the output
 func (ts *TrySql) Query(query string, report bool) (string, error) {
-	result, err := ts.outputCommandRaw(ts.mysqlArgs(query))
+	return ts.QueryContext(context.Background(), query, report)
+}
+
+// QueryContext is Query, cancelled along with ctx
+func (ts *TrySql) QueryContext(ctx context.Context, query string, report bool) (string, error) {
+	result, err := ts.execInContainer(ctx, ts.clientArgs(), strings.NewReader(query))
 	result = ts.parseQueryResult(result)
 	if err != nil {
 		errString := strings.Split(err.Error(), "\n")
-		errors := make([]string, 0)
+		messages := make([]string, 0)
 		for _, errMessage := range errString {
-			if len(errMessage) > 0 && !strings.Contains(errMessage, securityWarning) {
-				errors = append(errors, errMessage)
+			if len(errMessage) > 0 && !ts.isWarning(errMessage) {
+				messages = append(messages, errMessage)
 			}
 		}
-		if report && len(errors) > 0 {
-			result = result + strings.Join(errors, " | ")
+		if report && len(messages) > 0 {
+			result = result + strings.Join(messages, " | ")
 		}
+		return result, ts.clientError(err)
 	}
 	return result, nil
 }
 
+// RunScript runs SQL read from script with the engine's client, returning the client's output
+// as is. Errors reported by the server are returned as a *QueryError
+func (ts *TrySql) RunScript(ctx context.Context, script io.Reader) (string, error) {
+	result, err := ts.execInContainer(ctx, ts.clientArgs(), script)
+	if err != nil {
+		return result, ts.clientError(err)
+	}
+	return result, nil
+}
+
+// Status reports healthy once the server accepts connections and starting until then. When the
+// container is not running its own state is reported instead, such as exited
+func (ts *TrySql) Status(ctx context.Context) (string, error) {
+	container, err := ts.runtime.Inspect(ctx, ts.ContainerID())
+	if err != nil {
+		return "", err
+	}
+	if container.State == nil {
+		return "unknown", nil
+	}
+	if !container.State.Running {
+		return container.State.Status, nil
+	}
+	if ts.engine.Readiness() == engines.ReadyHealthcheck && container.State.Health != nil {
+		return container.State.Health.Status, nil
+	}
+	ready, err := ts.probe(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ready {
+		return "healthy", nil
+	}
+	return "starting", nil
+}
+
+// Logs returns the last lines the server wrote, or all of them when tail is less than one
+func (ts *TrySql) Logs(ctx context.Context, tail int) (string, error) {
+	logs, err := ts.runtime.Logs(ctx, ts.ContainerID(), tail)
+	return utils.Redact(logs, ts.secrets()...), ts.redact(err)
+}
+
+// ShellCommand is the command line that opens the engine's client interactively in the sandbox
+func (ts *TrySql) ShellCommand() []string {
+	command := []string{ts.runtime.Name(), "exec", "-it", ts.ContainerID()}
+	return append(command, ts.engine.ShellArgs()...)
+}
+
 func (ts *TrySql) GetDetails(details []string) string {
 	var property string
 	var err error
-	err = ts.setInspectData()
+	err = ts.setInspectData(context.Background())
 	if err != nil {
-		return err.Error()
+		return ts.redact(err).Error()
 	}
 	if len(details) < 1 {
 		property, err = ts.getJSON("[0]")
@@ -150,372 +311,544 @@ func (ts *TrySql) parseQueryResult(result string) string {
 	splitR := strings.Split(result, "\n")
 	results := make([]string, 0)
 	for _, res := range splitR {
-		if len(res) > 0 && !strings.Contains(res, securityWarning) {
+		if len(res) > 0 && !ts.isWarning(res) {
 			results = append(results, res)
 		}
 	}
 	return strings.Join(results, " | ")
 }
 
+// isWarning reports whether a line of client output is one of the engine's expected warnings
+func (ts *TrySql) isWarning(line string) bool {
+	for _, warning := range ts.engine.Warnings() {
+		if strings.Contains(line, warning) {
+			return true
+		}
+	}
+	return false
+}
+
 func (ts *TrySql) TearDown() error {
-	running, err := ts.containerRunning()
-	if !running {
-		return nil
+	return ts.TearDownContext(context.Background())
+}
+
+// TearDownContext stops and removes the sandbox, giving up when ctx is cancelled. A container
+// that has already stopped is just removed
+func (ts *TrySql) TearDownContext(ctx context.Context) error {
+	err := ts.closeDatabase()
+	if err != nil {
+		return err
 	}
+	running, err := ts.containerRunning(ctx)
 	if err != nil {
 		return err
 	}
-	fmt.Println("tearing down")
-	err = ts.waitAndWrite(ts.stoppingContainer, "stopping container")
+	if !running {
+		return ts.needsCleanup(ctx)
+	}
+	ts.reporter.Info("tearing down")
+	timeout := ts.Configs.GetTeardownTimeout()
+	err = ts.waitAndWrite(ctx, ts.stoppingContainer, "stopping container", timeout)
 	if err != nil {
 		return err
 	}
-	err = ts.waitAndWrite(ts.removingContainer, "removing container")
-	fmt.Println("destroyed")
+	err = ts.waitAndWrite(ctx, ts.removingContainer, "removing container", timeout)
+	ts.reporter.Info("destroyed")
 	return err
 }
 
+// abandon removes a container that failed to start, returning the error that stopped it. The
+// removal gets its own deadline since the startup's context may be what was cancelled
+func (ts *TrySql) abandon(cause error) error {
+	if len(ts.hash) < 1 {
+		return cause
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ts.Configs.GetTeardownTimeout())
+	defer cancel()
+	ts.runtime.Remove(ctx, ts.ContainerID(), true)
+	return cause
+}
+
 func (ts *TrySql) Password() string {
-	return ts.docker.Password
+	return ts.password
 }
 
-func (ts *TrySql) mysqlArgs(query string) string {
-	return fmt.Sprintf(
-		"exec TrySql mysql --user=root --password=\"%s\" --execute=\"%s\" --connect-expired-password",
-		ts.Password(),
-		query,
-	)
+// clientArgs runs the engine's client in the container. Queries are written to its stdin and the
+// password is read from the option file, so neither reaches a command line or needs quoting
+func (ts *TrySql) clientArgs() []string {
+	return ts.engine.ClientArgs()
 }
 
 func (ts *TrySql) GetContainerDetails(idOnly bool) string {
-	containers, err := ts.ps()
+	return ts.containerDetails(context.Background(), idOnly)
+}
+
+func (ts *TrySql) containerDetails(ctx context.Context, idOnly bool) string {
+	containers, err := ts.ps(ctx)
 	if err != nil {
 		return "something went wrong while trying to get the container's details"
 	}
-	result, err := ts.findContainer(containers)
+	container, err := ts.findContainer(containers)
 	if err != nil {
 		return err.Error()
 	}
-	if !idOnly {
-		return result
+	if idOnly {
+		return shortID(container.ID)
 	}
-	ts.filterContainerID(&result)
-	return result
+	return fmt.Sprintf(
+		"%s   %s   %s   %s",
+		shortID(container.ID),
+		container.Image,
+		container.Status,
+		strings.Join(container.Names, ","),
+	)
 }
 
-func (ts *TrySql) setHealthyStatus() error {
-	timeout := 0
+// setHealthyStatus probes the container every second until it is ready, it fails or ctx is done
+func (ts *TrySql) setHealthyStatus(ctx context.Context) error {
 	wait := time.NewTicker(time.Second)
-	status := make(chan bool, 1)
-	errLog := make(chan error, 1)
-	ts.getHealthStatus(status, errLog)
+	defer wait.Stop()
 	for {
-		select {
-		case err := <-errLog:
+		ready, err := ts.probe(ctx)
+		if err != nil || ready {
 			return err
-		case <-status:
-			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-wait.C:
-			timeout += 1
-			ts.getHealthStatus(status, errLog)
-			if timeout >= 120 {
-				return fmt.Errorf("timed out while waiting for container temporary password")
-			}
 		}
 	}
 }
 
-func (ts *TrySql) setInspectData() error {
-	result, err := ts.outputCommand([]string{"inspect", "TrySql"})
+// setInspectData refreshes the container's details, taking the host port from its bindings
+func (ts *TrySql) setInspectData(ctx context.Context) error {
+	result, err := ts.runtime.InspectRaw(ctx, ts.ContainerID())
 	if err != nil {
 		return err
 	}
+	// Wrapped in an array so that details are addressed the same way as docker inspect output. The
+	// secrets are redacted as they appear in JSON, where quotes and backslashes are escaped
+	secrets := ts.secrets()
+	for _, secret := range ts.secrets() {
+		encoded, _ := json.Marshal(secret)
+		secrets = append(secrets, strings.Trim(string(encoded), `"`))
+	}
 	ts.Details = &jsonextract.JSONExtract{
-		RawJSON: strings.Trim(strings.Trim(result, " "), "\n"),
+		RawJSON: "[" + utils.Redact(strings.TrimSpace(string(result)), secrets...) + "]",
 	}
-	return nil
-}
-
-func (ts *TrySql) getHealthStatus(status chan bool, errorChan chan error) {
-	details := ts.GetContainerDetails(false)
-	details = strings.ToLower(details)
-	if strings.Contains(details, "(health: starting)") {
-		return
+	inspect := &docker.ContainerJSON{}
+	err = json.Unmarshal(result, inspect)
+	if err != nil {
+		return err
 	}
-	if strings.Contains(details, "(healthy)") {
-		status <- true
-		return
+	port := publishedPort(ts.engine, inspect)
+	if port > 0 {
+		ts.hostPort = port
 	}
-	errorChan <- errors.New("no startup activity on container")
+	return nil
 }
 
-func (ts *TrySql) listContainers(all bool) ([]string, error) {
-	args := []string{"container", "ls"}
-	if all {
-		args = append(args, "-al")
-	}
-	containers, err := ts.outputCommand(args)
+// probe inspects the container and, once it is running, checks the server by the engine's
+// readiness strategy. It reports whether the server is ready, failing when it never will be
+func (ts *TrySql) probe(ctx context.Context) (bool, error) {
+	container, err := ts.runtime.Inspect(ctx, ts.ContainerID())
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	existingContainers := strings.Split(containers, "\n")
-	if len(existingContainers) > 0 {
-		if strings.Contains(existingContainers[0], "CONTAINER ID") {
-			existingContainers = existingContainers[1:]
+	state := container.State
+	switch {
+	case state == nil || state.Status == "created" || state.Restarting:
+		return false, nil
+	case !state.Running:
+		return false, ts.startupError(ctx, state)
+	}
+	switch ts.engine.Readiness() {
+	case engines.ReadyPing:
+		_, err = ts.execInContainer(ctx, ts.engine.PingArgs(), nil)
+		return err == nil, nil
+	case engines.ReadyTCP:
+		return engines.Dial(ts.engine, ts.Address(), time.Second) == nil, nil
+	case engines.ReadyQuery:
+		return engines.Query(ctx, ts.engine, ts.endpoint(), probeTimeout) == nil, nil
+	}
+	return healthcheckStatus(state.Health)
+}
+
+// healthcheckStatus reads the image's own HEALTHCHECK, which is starting until it first passes
+func healthcheckStatus(health *docker.Health) (bool, error) {
+	if health == nil {
+		return false, errors.New("the image defines no HEALTHCHECK, choose another readiness strategy: ping, tcp or query")
+	}
+	switch health.Status {
+	case "healthy":
+		return true, nil
+	case "unhealthy":
+		message := "the image's HEALTHCHECK reports the server unhealthy"
+		if len(health.Log) > 0 {
+			message += ": " + strings.TrimSpace(health.Log[len(health.Log)-1].Output)
 		}
-		return existingContainers, nil
+		return false, errors.New(message)
 	}
-
-	return []string{}, nil
+	return false, nil
 }
 
-func (ts *TrySql) ps() ([]string, error) {
-	containers, err := ts.outputCommand([]string{"ps"})
-	if err != nil {
-		return nil, err
-	}
-	runningContainers := strings.Split(containers, "\n")
-	if len(runningContainers) > 0 {
-		if strings.Contains(runningContainers[0], "CONTAINER ID") {
-			runningContainers = runningContainers[1:]
-		}
-		return runningContainers, nil
+// startupError describes a container that stopped before its server was ready, with the end of its log
+func (ts *TrySql) startupError(ctx context.Context, state *docker.ContainerState) error {
+	logs, _ := ts.Logs(ctx, startupLogLines)
+	return &StartupError{
+		Status:    state.Status,
+		ExitCode:  state.ExitCode,
+		OOMKilled: state.OOMKilled,
+		Logs:      strings.TrimSpace(logs),
 	}
+}
 
-	return []string{}, nil
+func (ts *TrySql) listContainers(ctx context.Context, all bool) ([]docker.ContainerSummary, error) {
+	return ts.runtime.List(ctx, all, nil)
 }
 
-func (ts *TrySql) provision() error {
-	msg := "pulling up to date image"
-	return ts.waitAndWrite(ts.provisioningDocker, msg)
+func (ts *TrySql) ps(ctx context.Context) ([]docker.ContainerSummary, error) {
+	return ts.listContainers(ctx, false)
 }
 
-func (ts *TrySql) waitForHealthy() error {
+// provision runs for as long as the pull keeps making progress, so that large images on slow
+// links are not cut off by a fixed deadline
+func (ts *TrySql) provision(ctx context.Context) error {
+	msg := provisionMessage(ts.Configs.GetPullPolicy())
+	progress := make(chan struct{}, 1)
+	err := ts.waitAndWriteProgress(ctx, ts.provisioningDocker(msg, progress), msg, ts.Configs.GetPullTimeout(), progress)
+	if err != nil {
+		return err
+	}
+	ts.reporter.Info("using " + ts.imageStatus.String())
+	return nil
+}
+
+func (ts *TrySql) waitForHealthy(ctx context.Context) error {
 	msg := "waiting for container to set up"
-	return ts.waitAndWrite(ts.waitingForHealtyStatus, msg)
+	return ts.waitAndWrite(ctx, ts.waitingForHealtyStatus, msg, ts.Configs.GetHealthTimeout())
 }
 
-func (ts *TrySql) containerRunning() (bool, error) {
-	exists, err := ts.containerExists(false)
+// prepare creates the configured databases and application user and then runs the seed scripts,
+// when there are any. An adopted container was prepared by the process that started it, and
+// seeding it again would fail on scripts that are not idempotent
+func (ts *TrySql) prepare(ctx context.Context) error {
+	if ts.adopted {
+		return nil
+	}
+	if len(ts.Configs.GetDatabases()) < 1 && len(ts.Configs.GetSeed()) < 1 && len(ts.user) < 1 {
+		return nil
+	}
+	msg := "creating databases and running seed scripts"
+	return ts.waitAndWrite(ctx, ts.preparingDatabases, msg, ts.Configs.GetSeedTimeout())
+}
+
+func (ts *TrySql) containerRunning(ctx context.Context) (bool, error) {
+	exists, err := ts.containerExists(ctx, false)
 	if err != nil {
 		return false, err
 	}
 	if !exists {
-		fmt.Println("container does not exist")
+		ts.reporter.Info("container does not exist")
 		return false, nil
 	}
-	running, err := ts.isRunning()
+	running, err := ts.isRunning(ctx)
 	if err != nil {
 		return false, err
 	}
 	if !running {
-		fmt.Println("container is not running")
+		ts.reporter.Info("container is not running")
 		return false, nil
 	}
 	return true, nil
 }
 
-func (ts *TrySql) run() error {
-	running, err := ts.containerExists(false)
+func (ts *TrySql) run(ctx context.Context) error {
+	running, err := ts.containerExists(ctx, false)
 	if err != nil {
 		return err
 	}
-	if running {
-		return nil
+	if !running {
+		return ts.runNew(ctx)
+	}
+	inspect, err := ts.runtime.Inspect(ctx, ts.ContainerID())
+	if err != nil {
+		return err
 	}
-	return ts.runNew()
+	ts.adopted = true
+	return ts.adopt(ctx, inspect)
+}
+
+// waitAndWrite runs a phase of the lifecycle under its own timeout while reporting its progress.
+// Phases are handed a context that ends with the timeout, and are expected to stop with it
+func (ts *TrySql) waitAndWrite(ctx context.Context, funcInterface interface{}, msg string, timeout time.Duration) error {
+	return ts.waitAndWriteProgress(ctx, funcInterface, msg, timeout, nil)
 }
 
-func (ts *TrySql) waitAndWrite(funcInterface interface{}, msg string) error {
-	functionCall, ok := (funcInterface).(func(*sync.WaitGroup, chan error))
+// waitAndWriteProgress is waitAndWrite for a phase that signals progress, whose timeout starts
+// over with every signal so that it only runs out once the phase has stalled
+func (ts *TrySql) waitAndWriteProgress(ctx context.Context, funcInterface interface{}, msg string, timeout time.Duration, progress <-chan struct{}) error {
+	functionCall, ok := (funcInterface).(func(context.Context, *sync.WaitGroup, chan error))
 	if !ok {
 		return fmt.Errorf("invalid function provided")
 	}
 	var err error
+	phaseCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	initChan := make(chan error, 1)
-	writer := uilive.New() // writer for the first line
 	wg := &sync.WaitGroup{}
-	writer.Start()
+	ts.reporter.Start(msg)
 	wg.Add(2)
-	go ts.wait(wg, initChan, writer, &err, msg)
-	go functionCall(wg, initChan)
+	go ts.wait(phaseCtx, cancel, wg, initChan, &err, msg, timeout, progress)
+	go functionCall(phaseCtx, wg, initChan)
 	wg.Wait()
 	close(initChan)
-	fmt.Fprintf(writer, msg+" %s\n", "done")
-	writer.Stop()
+	ts.reporter.Done(msg, err)
 	return err
 }
 
-func (ts *TrySql) initDocker() error {
-	return ts.docker.SetVersion()
+func (ts *TrySql) initRuntime(ctx context.Context) error {
+	version, err := ts.runtime.Version(ctx)
+	if err != nil {
+		return err
+	}
+	ts.version = version
+	return nil
 }
 
-func (ts *TrySql) findContainer(containers []string) (string, error) {
+func (ts *TrySql) findContainer(containers []docker.ContainerSummary) (docker.ContainerSummary, error) {
 	for _, container := range containers {
-		if strings.Contains(container, "TrySql") {
+		if ts.isOwnContainer(container) {
 			return container, nil
 		}
 	}
-	return "", errors.New("not found")
+	return docker.ContainerSummary{}, errors.New("not found")
+}
+
+// isOwnContainer reports whether a listed container belongs to this sandbox,
+// matching on the container ID when it is known and on the unique name otherwise
+func (ts *TrySql) isOwnContainer(container docker.ContainerSummary) bool {
+	if len(ts.hash) > 0 && container.ID == ts.hash {
+		return true
+	}
+	for _, name := range container.Names {
+		if strings.TrimPrefix(name, "/") == ts.name {
+			return true
+		}
+	}
+	return false
 }
 
-func (ts *TrySql) containerExists(all bool) (bool, error) {
-	containers, err := ts.listContainers(all)
+func (ts *TrySql) containerExists(ctx context.Context, all bool) (bool, error) {
+	containers, err := ts.listContainers(ctx, all)
 	if err != nil {
 		return false, err
 	}
 	for _, container := range containers {
-		if strings.Contains(container, "TrySql") {
+		if ts.isOwnContainer(container) {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
-func (ts *TrySql) filterContainerID(containerDetails *string) {
-	*containerDetails = strings.ReplaceAll(*containerDetails, "\n", " ")
-	*containerDetails = strings.ReplaceAll(*containerDetails, "\t", " ")
-	splitC := strings.Split(*containerDetails, " ")
-	if len(splitC) < 1 {
-		*containerDetails = ""
-	}
-	*containerDetails = splitC[0]
-}
-
-func (ts *TrySql) isRunning() (bool, error) {
-	containers, err := ts.ps()
+func (ts *TrySql) isRunning(ctx context.Context) (bool, error) {
+	containers, err := ts.ps(ctx)
 	if err != nil {
 		return false, err
 	}
 	for _, container := range containers {
-		if strings.Contains(container, "TrySql") {
+		if ts.isOwnContainer(container) {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
-func (ts *TrySql) runNew() error {
+func (ts *TrySql) runNew(ctx context.Context) error {
 	msg := "waiting for container connection"
-	return ts.waitAndWrite(ts.settingUpContainer, msg)
+	return ts.waitAndWrite(ctx, ts.settingUpContainer, msg, ts.Configs.GetStartTimeout())
 }
 
-func (ts *TrySql) wait(wg *sync.WaitGroup, initChan chan error, writer *uilive.Writer, err *error, msg string) {
-	timeOut := 0
-	updating := []string{"|", "/", "-", "\\"}
-	uIndex := 0
+// wait blocks until the phase reports back, its timer runs out or its context ends. Every signal
+// on progress starts the timer over, and a nil progress leaves it a fixed deadline. When the timer
+// runs out the phase is cancelled, and its own result is left in the buffered channel
+func (ts *TrySql) wait(ctx context.Context, cancel context.CancelFunc, wg *sync.WaitGroup, initChan chan error, err *error, msg string, timeout time.Duration, progress <-chan struct{}) {
+	defer wg.Done()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 	for {
 		select {
 		case *err = <-initChan:
-			wg.Done()
 			return
-		default:
-			fmt.Fprintf(writer, msg+" %s\n", updating[uIndex])
-			if timeOut > 900 {
-				*err = fmt.Errorf("timed out")
-				initChan <- *err
-				wg.Done()
-				return
+		case <-progress:
+			if !timer.Stop() {
+				<-timer.C
 			}
-			if uIndex == 3 {
-				uIndex = 0
-			} else {
-				uIndex++
+			timer.Reset(timeout)
+		case <-timer.C:
+			cancel()
+			*err = fmt.Errorf("timed out after %s while %s", timeout, msg)
+			if progress != nil {
+				*err = fmt.Errorf("timed out after %s without progress while %s", timeout, msg)
 			}
-			timeOut++
-			time.Sleep(time.Millisecond * 200)
+			return
+		case <-ctx.Done():
+			*err = ctx.Err()
+			return
 		}
 	}
 }
 
-func (ts *TrySql) settingUpContainer(wg *sync.WaitGroup, initChan chan error) {
+func (ts *TrySql) settingUpContainer(ctx context.Context, wg *sync.WaitGroup, initChan chan error) {
 	defer wg.Done()
-	err := ts.needsCleanup()
+	err := ts.needsCleanup(ctx)
+	if err == nil {
+		err = ts.checkPort(ctx)
+	}
 	if err != nil {
 		initChan <- err
 		return
 	}
-	result, err := ts.outputCommand(ts.getRunCommand())
-	ts.hash = result
+	id, err := ts.runtime.Run(ctx, ts.name, ts.getContainerConfig())
+	if len(id) > 0 {
+		ts.hash = id
+	}
+	if err == nil {
+		// The port chosen by the runtime, when it was left to it, is only known once the container runs
+		err = ts.setInspectData(ctx)
+	}
 	ts.ReadyState = 1
 	initChan <- err
 }
 
-func (ts *TrySql) getRunCommand() []string {
-	return []string{
-		"run",
-		"-d",
-		"--expose",
-		"3306",
-		"-e",
-		"MYSQL_ROOT_HOST=%",
-		"-e",
-		"MYSQL_ROOT_PASSWORD=" + ts.Password(),
-		"-p",
-		ts.HostPortStr() + ":3306",
-		"--name=TrySql",
-		ts.image,
-	}
+// getContainerConfig leaves the image's own command in place unless there are server variables to pass
+func (ts *TrySql) getContainerConfig() *docker.ContainerConfig {
+	config := &docker.ContainerConfig{
+		Image: ts.image,
+		Env:   ts.engine.Env(),
+		Files: ts.credentialFiles(),
+		Labels: map[string]string{
+			labelEngine:    ts.engine.Name(),
+			labelReadiness: ts.engine.Readiness(),
+		},
+		ExposedPorts: map[string]struct{}{
+			ts.engine.Port(): {},
+		},
+		HostConfig: &docker.HostConfig{
+			PortBindings: map[string][]docker.PortBinding{
+				ts.engine.Port(): {{HostPort: ts.bindingPort()}},
+			},
+			Memory:   ts.Configs.GetMemory(),
+			NanoCPUs: int64(ts.Configs.GetCPUs() * 1e9),
+		},
+	}
+	for label, value := range ts.lifecycleLabels(time.Now()) {
+		config.Labels[label] = value
+	}
+	if len(ts.user) > 0 {
+		config.Labels[labelUser] = ts.user
+	}
+	variables := ts.Configs.GetVariables()
+	if len(variables) > 0 {
+		config.Cmd = ts.engine.ServerArgs(variables)
+	}
+	return config
 }
 
 func (ts *TrySql) HostPortStr() string {
-	return strconv.Itoa(ts.docker.HostPort)
+	return strconv.Itoa(ts.hostPort)
 }
 
-func (ts *TrySql) needsCleanup() error {
-	exists, err := ts.containerExists(true)
+func (ts *TrySql) needsCleanup(ctx context.Context) error {
+	exists, err := ts.containerExists(ctx, true)
 	if err != nil {
 		return err
 	}
 	if !exists {
 		return nil
 	}
-	return ts.cleanUp()
+	return ts.cleanUp(ctx)
 }
 
-func (ts *TrySql) cleanUp() error {
-	ts.outputCommand([]string{"stop", "TrySql"})
-	_, err := ts.outputCommand([]string{"rm", "TrySql"})
-	return err
+func (ts *TrySql) cleanUp(ctx context.Context) error {
+	return ts.runtime.Remove(ctx, ts.ContainerID(), true)
+}
+
+func (ts *TrySql) provisioningDocker(msg string, progress chan struct{}) func(context.Context, *sync.WaitGroup, chan error) {
+	return func(ctx context.Context, wg *sync.WaitGroup, initChan chan error) {
+		defer wg.Done()
+		initChan <- ts.provisionImage(ctx, ts.reportPull(msg, progress))
+	}
 }
 
-func (ts *TrySql) provisioningDocker(wg *sync.WaitGroup, initChan chan error) {
+func (ts *TrySql) waitingForHealtyStatus(ctx context.Context, wg *sync.WaitGroup, initChan chan error) {
 	defer wg.Done()
-	_, err := ts.outputCommand([]string{"pull", ts.image})
-	initChan <- err
+	initChan <- ts.setHealthyStatus(ctx)
 }
 
-func (ts *TrySql) waitingForHealtyStatus(wg *sync.WaitGroup, initChan chan error) {
+func (ts *TrySql) preparingDatabases(ctx context.Context, wg *sync.WaitGroup, initChan chan error) {
 	defer wg.Done()
-	initChan <- ts.setHealthyStatus()
+	for _, database := range ts.Configs.GetDatabases() {
+		_, err := ts.RunScript(ctx, strings.NewReader(ts.engine.CreateDatabase(database)))
+		if err != nil {
+			initChan <- fmt.Errorf("creating database %s: %w", database, err)
+			return
+		}
+	}
+	err := ts.createUser(ctx)
+	if err != nil {
+		initChan <- err
+		return
+	}
+	for _, script := range ts.Configs.GetSeed() {
+		err := ts.runSeed(ctx, script)
+		if err != nil {
+			initChan <- fmt.Errorf("running seed script %s: %w", script, err)
+			return
+		}
+	}
+	initChan <- nil
+}
+
+func (ts *TrySql) runSeed(ctx context.Context, script string) error {
+	file, err := os.Open(script)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = ts.RunScript(ctx, file)
+	return err
 }
 
-func (ts *TrySql) stoppingContainer(wg *sync.WaitGroup, initChan chan error) {
+func (ts *TrySql) stoppingContainer(ctx context.Context, wg *sync.WaitGroup, initChan chan error) {
 	defer wg.Done()
-	_, err := ts.outputCommand([]string{"container", "stop", "TrySql"})
-	initChan <- err
+	initChan <- ts.runtime.Stop(ctx, ts.ContainerID(), 10)
 }
 
-func (ts *TrySql) removingContainer(wg *sync.WaitGroup, initChan chan error) {
+func (ts *TrySql) removingContainer(ctx context.Context, wg *sync.WaitGroup, initChan chan error) {
 	defer wg.Done()
-	_, err := ts.outputCommand([]string{"container", "rm", "TrySql"})
-	initChan <- err
+	initChan <- ts.runtime.Remove(ctx, ts.ContainerID(), false)
 }
 
-func (ts *TrySql) outputCommand(args []string) (string, error) {
-	result, err := ts.docker.Com().Args(args).Exec()
+// execInContainer runs a command in the sandbox, failing with the command's stderr when it exits non-zero
+func (ts *TrySql) execInContainer(ctx context.Context, cmd []string, stdin io.Reader) (string, error) {
+	result, err := ts.runtime.Exec(ctx, ts.ContainerID(), cmd, stdin)
 	if err != nil {
-		return "", err
+		return "", ts.redact(err)
 	}
-	return result, nil
+	if result.ExitCode != 0 {
+		return result.Stdout, &exitError{code: result.ExitCode, stderr: utils.Redact(result.Stderr, ts.secrets()...)}
+	}
+	return result.Stdout, nil
 }
 
-func (ts *TrySql) outputCommandRaw(arg string) (string, error) {
-	result, err := ts.docker.Com().ExecRaw(arg)
-	if err != nil {
-		return "", err
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
 	}
-	return result, nil
+	return id
 }
 
 func getArgs() []string {
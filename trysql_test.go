@@ -1,12 +1,22 @@
 package trysql
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	jsonextract "github.com/blainemoser/JsonExtract"
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/docker"
+	"github.com/blainemoser/TrySql/engines"
+	"github.com/blainemoser/TrySql/reporters"
+	"github.com/blainemoser/TrySql/runtimes"
 	"github.com/blainemoser/TrySql/utils"
 )
 
@@ -18,7 +28,7 @@ func TestMain(m *testing.M) {
 	Testing = true
 	code := m.Run()
 	Testing = false
-	if !torn {
+	if !torn && tsql != nil {
 		err := tsql.TearDown()
 		if err != nil {
 			panic(err)
@@ -35,13 +45,13 @@ func TestInitialize(t *testing.T) {
 func TestListContainers(t *testing.T) {
 	defer utils.HandelPanic(t)
 	tInit()
-	result, err := tsql.listContainers(false)
+	result, err := tsql.listContainers(context.Background(), false)
 	if err != nil {
 		t.Error(err)
 	}
 	expects := "mysql/mysql-server:latest"
 	for _, container := range result {
-		if strings.Contains(container, expects) {
+		if container.Image == expects {
 			return
 		}
 	}
@@ -74,8 +84,7 @@ func TestMySQLCommand(t *testing.T) {
 	tInit()
 	result := tsql.MySQLCommand()
 	expects := fmt.Sprintf(
-		"mysql -uroot -p%s -h127.0.0.1 -P%s",
-		tsql.Password(),
+		"mysql -uroot -p -h127.0.0.1 -P%s",
 		tsql.HostPortStr(),
 	)
 	if result != expects {
@@ -100,11 +109,195 @@ func TestGetContainerDetails(t *testing.T) {
 	}
 }
 
-func TestMysqlArgs(t *testing.T) {
+func TestClientArgs(t *testing.T) {
 	defer utils.HandelPanic(t)
-	result := tsql.mysqlArgs("QUERY")
-	if !strings.Contains(result, "--execute=\"QUERY\"") {
-		t.Errorf("expected raw string to contain '--execute=\"QUERY\"', got %s", result)
+	result := tsql.clientArgs()
+	for _, arg := range result {
+		if strings.HasPrefix(arg, "--execute") {
+			t.Errorf("expected queries not to be passed on the command line, got %s", strings.Join(result, " "))
+		}
+		if strings.Contains(arg, tsql.Password()) {
+			t.Errorf("expected the password not to be passed on the command line, got %s", strings.Join(result, " "))
+		}
+	}
+	if result[0] != "mysql" {
+		t.Errorf("expected the mysql client, got %s", result[0])
+	}
+}
+
+func TestQuerySpecialCharacters(t *testing.T) {
+	defer utils.HandelPanic(t)
+	result, err := tsql.Query("SELECT JSON_OBJECT('quote', '\"', 'shell', '$(echo injected)', 'tick', '`') AS js", false)
+	if err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(result, "$(echo injected)") {
+		t.Errorf("expected the query's text to reach the server untouched, got '%s'", result)
+	}
+}
+
+func TestIsOwnContainer(t *testing.T) {
+	defer utils.HandelPanic(t)
+	ts := &TrySql{name: "TrySql-0a1b2c3d4e5f"}
+	row := docker.ContainerSummary{
+		ID:    "0123456789abcdef0123456789abcdef",
+		Names: []string{"/TrySql-0a1b2c3d4e5f"},
+	}
+	other := docker.ContainerSummary{
+		ID:    "ba9876543210fedcba9876543210fedc",
+		Names: []string{"/TrySql-ffffffffffff"},
+	}
+	if !ts.isOwnContainer(row) {
+		t.Errorf("expected row to match container by name")
+	}
+	if ts.isOwnContainer(other) {
+		t.Errorf("expected another sandbox's container not to match")
+	}
+	ts.hash = "0123456789abcdef0123456789abcdef"
+	ts.name = ""
+	if !ts.isOwnContainer(row) {
+		t.Errorf("expected row to match container by ID")
+	}
+}
+
+func TestPhaseTimeout(t *testing.T) {
+	defer utils.HandelPanic(t)
+	ts := &TrySql{Configs: testConfigs(t), reporter: reporters.Silent{}}
+	stalled := func(ctx context.Context, wg *sync.WaitGroup, initChan chan error) {
+		defer wg.Done()
+		<-ctx.Done()
+		initChan <- ctx.Err()
+	}
+	err := ts.waitAndWrite(context.Background(), stalled, "stalling", 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms while stalling") {
+		t.Errorf("expected the phase to time out, got %v", err)
+	}
+	progress := make(chan struct{})
+	moving := func(ctx context.Context, wg *sync.WaitGroup, initChan chan error) {
+		defer wg.Done()
+		for i := 0; i < 6; i++ {
+			time.Sleep(20 * time.Millisecond)
+			progress <- struct{}{}
+		}
+		initChan <- nil
+	}
+	err = ts.waitAndWriteProgress(context.Background(), moving, "pulling", 50*time.Millisecond, progress)
+	if err != nil {
+		t.Errorf("expected a phase making progress to outlast its timeout, got %v", err)
+	}
+	err = ts.waitAndWriteProgress(context.Background(), stalled, "pulling", 50*time.Millisecond, progress)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms without progress while pulling") {
+		t.Errorf("expected a stalled phase to time out, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ts.waitAndWrite(ctx, stalled, "stalling", time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the phase to be cancelled, got %v", err)
+	}
+}
+
+func TestDetailsRedacted(t *testing.T) {
+	defer utils.HandelPanic(t)
+	engine, err := engines.New(testConfigs(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := `{"Id":"0123456789abcdef","Config":{"Env":["MYSQL_ROOT_PASSWORD=s3\\cret","APP_PASSWORD=app-pass"]}}`
+	ts := &TrySql{runtime: &rawRuntime{raw: raw}, engine: engine, password: `s3\cret`, userPassword: "app-pass"}
+	details := ts.GetDetails([]string{"Config/Env"})
+	if strings.Contains(details, "s3") || strings.Contains(details, "app-pass") || !strings.Contains(details, utils.Redacted) {
+		t.Errorf("expected the passwords to be redacted from the details, got '%s'", details)
+	}
+}
+
+// rawRuntime answers every inspect with the same document
+type rawRuntime struct {
+	runtimes.Runtime
+	raw string
+}
+
+func (r *rawRuntime) InspectRaw(ctx context.Context, id string) ([]byte, error) {
+	return []byte(r.raw), nil
+}
+
+func TestPrepareSkipsAdopted(t *testing.T) {
+	defer utils.HandelPanic(t)
+	ts := &TrySql{Configs: testConfigs(t, "--databases", "app", "--seed", "schema.sql"), reporter: reporters.Silent{}, adopted: true}
+	err := ts.prepare(context.Background())
+	if err != nil {
+		t.Errorf("expected an adopted container not to be seeded again, got %v", err)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	defer utils.HandelPanic(t)
+	healthcheck, err := engines.New(testConfigs(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ping, err := engines.New(testConfigs(t, "--engine", "mariadb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	probes := []struct {
+		engine engines.Engine
+		state  *docker.ContainerState
+		ready  bool
+		err    string
+	}{
+		{healthcheck, &docker.ContainerState{Status: "created"}, false, ""},
+		{healthcheck, &docker.ContainerState{Status: "running", Running: true, Health: &docker.Health{Status: "starting"}}, false, ""},
+		{healthcheck, &docker.ContainerState{Status: "running", Running: true, Health: &docker.Health{Status: "healthy"}}, true, ""},
+		{healthcheck, &docker.ContainerState{Status: "running", Running: true}, false, "the image defines no HEALTHCHECK"},
+		{healthcheck, &docker.ContainerState{Status: "running", Running: true, Health: &docker.Health{
+			Status: "unhealthy",
+			Log:    []docker.HealthLog{{Output: "mysqld is not answering\n"}},
+		}}, false, "reports the server unhealthy: mysqld is not answering"},
+		{ping, &docker.ContainerState{Status: "running", Running: true}, true, ""},
+		{ping, &docker.ContainerState{Status: "exited", ExitCode: 1}, false, "container exited with exit code 1 while starting; last lines of its log:\n[Note] starting\n[ERROR] unknown variable 'max_conections=10'"},
+	}
+	for i, probe := range probes {
+		ts := &TrySql{engine: probe.engine, password: "s3cret", runtime: &stateRuntime{
+			inspect: &docker.ContainerJSON{State: probe.state},
+			logs:    "[Note] starting\n[ERROR] unknown variable 'max_conections=10'\n",
+		}}
+		ready, err := ts.probe(context.Background())
+		if ready != probe.ready || (err == nil) != (len(probe.err) < 1) || (err != nil && !strings.Contains(err.Error(), probe.err)) {
+			t.Errorf("expected probe %d to give %t and '%s', got %t and %v", i, probe.ready, probe.err, ready, err)
+		}
+	}
+	ts := &TrySql{engine: ping, runtime: &stateRuntime{inspect: &docker.ContainerJSON{
+		State: &docker.ContainerState{Status: "exited", ExitCode: 137, OOMKilled: true},
+	}}}
+	_, err = ts.probe(context.Background())
+	startup := &StartupError{}
+	if !errors.As(err, &startup) || startup.ExitCode != 137 || !strings.Contains(err.Error(), "out of memory") {
+		t.Errorf("expected a startup error for a container killed for memory, got %v", err)
+	}
+}
+
+func TestContainerConfig(t *testing.T) {
+	defer utils.HandelPanic(t)
+	cnfs := testConfigs(t, "--engine", "postgres", "--variable", "max_connections=500", "--memory", "512m", "--cpus", "1.5")
+	engine, err := engines.New(cnfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := &TrySql{engine: engine, hostPort: 5433, Configs: cnfs}
+	config := ts.getContainerConfig()
+	if strings.Join(config.Cmd, " ") != "postgres -c max_connections=500" {
+		t.Errorf("expected the server variables in the command, got %v", config.Cmd)
+	}
+	if config.HostConfig.Memory != 512<<20 || config.HostConfig.NanoCPUs != 1500000000 {
+		t.Errorf("expected 512m and 1.5 cpus, got %d and %d", config.HostConfig.Memory, config.HostConfig.NanoCPUs)
+	}
+	if config.Labels[labelEngine] != "postgres" {
+		t.Errorf("expected the engine label, got %v", config.Labels)
+	}
+	ts.Configs = testConfigs(t, "--engine", "postgres")
+	if ts.getContainerConfig().Cmd != nil {
+		t.Errorf("expected the image's own command without server variables")
 	}
 }
 
@@ -119,6 +312,117 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+func TestQueryError(t *testing.T) {
+	defer utils.HandelPanic(t)
+	_, err := tsql.Query("SELECT * FROM mysql.does_not_exist", false)
+	queryErr := &QueryError{}
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("expected a QueryError, got %v", err)
+	}
+	if queryErr.Number != 1146 || queryErr.SQLState != "42S02" {
+		t.Errorf("expected error 1146 (42S02), got %d (%s)", queryErr.Number, queryErr.SQLState)
+	}
+}
+
+func TestQueryRows(t *testing.T) {
+	defer utils.HandelPanic(t)
+	_, err := tsql.QueryRows("CREATE TABLE test.rows (id INT AUTO_INCREMENT PRIMARY KEY, label VARCHAR(32), score DOUBLE)")
+	if err != nil {
+		_, err = tsql.QueryRows("CREATE DATABASE test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tsql.QueryRows("CREATE TABLE test.rows (id INT AUTO_INCREMENT PRIMARY KEY, label VARCHAR(32), score DOUBLE)")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	inserted, err := tsql.QueryRows("INSERT INTO test.rows (label, score) VALUES (?, ?), (?, ?)", "a | b", 1.5, "c\nd", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted.RowsAffected != 2 || inserted.LastInsertID != 1 {
+		t.Errorf("expected 2 rows affected from insert ID 1, got %d from %d", inserted.RowsAffected, inserted.LastInsertID)
+	}
+	result, err := tsql.QueryRows("SELECT id, label, score FROM test.rows ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rows) != 2 || strings.Join(result.Columns, ",") != "id,label,score" {
+		t.Fatalf("expected 2 rows of id, label and score, got %v", result)
+	}
+	label, _ := result.Value(0, "label")
+	if label != "a | b" {
+		t.Errorf("expected label 'a | b', got '%v'", label)
+	}
+	score, _ := result.Value(1, "score")
+	if score != nil {
+		t.Errorf("expected a NULL score, got '%v'", score)
+	}
+	id, _ := result.Value(1, "id")
+	if id != int64(2) {
+		t.Errorf("expected id 2, got '%v'", id)
+	}
+}
+
+func TestSession(t *testing.T) {
+	defer utils.HandelPanic(t)
+	session, err := tsql.Session()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	statements := []string{
+		"SET @greeting = 'hello'",
+		"CREATE TEMPORARY TABLE mysql.session_test (id INT)",
+		"INSERT INTO mysql.session_test VALUES (1)",
+	}
+	for _, statement := range statements {
+		_, err = session.Query(statement)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = session.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = session.Query("INSERT INTO mysql.session_test VALUES (2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = session.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := session.Query("SELECT @greeting AS greeting, COUNT(*) AS total FROM mysql.session_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	greeting, _ := result.Value(0, "greeting")
+	total, _ := result.Value(0, "total")
+	if greeting != "hello" || total != int64(1) {
+		t.Errorf("expected the variable and temporary table to persist without the rolled back row, got %v and %v", greeting, total)
+	}
+}
+
+func TestDB(t *testing.T) {
+	defer utils.HandelPanic(t)
+	db, err := tsql.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var one int
+	err = db.QueryRow("SELECT 1").Scan(&one)
+	if err != nil {
+		t.Error(err)
+	}
+	if one != 1 {
+		t.Errorf("expected 1, got %d", one)
+	}
+}
+
 func TestDetails(t *testing.T) {
 	defer utils.HandelPanic(t)
 	result := tsql.GetDetails([]string{"details", "Id", "State/Health/Log"})
@@ -144,7 +448,7 @@ func TestDetails(t *testing.T) {
 
 func TestContainerRunning(t *testing.T) {
 	defer utils.HandelPanic(t)
-	result, err := tsql.containerRunning()
+	result, err := tsql.containerRunning(context.Background())
 	if err != nil {
 		t.Error(err)
 	}
@@ -173,3 +477,30 @@ func tInit() {
 		initialised = true
 	}
 }
+
+// stateRuntime reports a container in a fixed state, whose commands all succeed
+type stateRuntime struct {
+	runtimes.Runtime
+	inspect *docker.ContainerJSON
+	logs    string
+}
+
+func (s *stateRuntime) Inspect(ctx context.Context, id string) (*docker.ContainerJSON, error) {
+	return s.inspect, nil
+}
+
+func (s *stateRuntime) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (*docker.ExecResult, error) {
+	return &docker.ExecResult{}, nil
+}
+
+func (s *stateRuntime) Logs(ctx context.Context, id string, tail int) (string, error) {
+	return s.logs, nil
+}
+
+func testConfigs(t *testing.T, args ...string) *configs.Configs {
+	cnfs, err := configs.New(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cnfs
+}
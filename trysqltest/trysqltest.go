@@ -0,0 +1,164 @@
+// Package trysqltest provisions TrySql sandboxes for tests, skipping them where there is no
+// container runtime and tearing the sandboxes down when the tests are done
+package trysqltest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	trysql "github.com/blainemoser/TrySql"
+	"github.com/blainemoser/TrySql/configs"
+	"github.com/blainemoser/TrySql/runtimes"
+)
+
+// How many lines of the server's log are dumped when a test fails
+const logTail = 200
+
+// Option configures the sandbox New provides
+type Option func(*options)
+
+type options struct {
+	args   []string
+	shared string
+}
+
+// WithArgs passes flags as trysql.Initialise takes them, e.g. WithArgs("--version", "8.0")
+func WithArgs(args ...string) Option {
+	return func(o *options) {
+		o.args = append(o.args, args...)
+	}
+}
+
+// WithEngine runs the named engine: mysql, mariadb or postgres
+func WithEngine(engine string) Option {
+	return WithArgs("--engine", engine)
+}
+
+// WithVersion runs the given version of the engine's image
+func WithVersion(version string) Option {
+	return WithArgs("--version", version)
+}
+
+// Shared reuses the sandbox of the given name, starting it only when it is not running yet.
+// It outlives the test, and the test binary, so that later tests and runs skip the startup.
+// Prune leaves it alone unless it has a TTL; remove it with trysql down --name
+func Shared(name string) Option {
+	return func(o *options) {
+		o.shared = name
+	}
+}
+
+var shared = struct {
+	sync.Mutex
+	sandboxes map[string]*trysql.TrySql
+}{
+	sandboxes: make(map[string]*trysql.TrySql),
+}
+
+// New returns a running sandbox for the test. Progress is not reported unless the args ask for
+// it. The test is skipped when no container runtime can be reached and fails when the sandbox
+// does not start. The server's log is written to the test's log if the test fails
+func New(t testing.TB, opts ...Option) *trysql.TrySql {
+	t.Helper()
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	args := o.arguments()
+	ctx, cancel := testContext(t)
+	defer cancel()
+	err := available(ctx, args)
+	if err != nil {
+		t.Skipf("trysqltest: no container runtime available: %s", err.Error())
+	}
+	if len(o.shared) > 0 {
+		ts := sharedSandbox(ctx, t, args, o.shared)
+		t.Cleanup(func() {
+			dumpLogs(t, ts)
+		})
+		return ts
+	}
+	ts, err := trysql.InitialiseContext(ctx, args)
+	if err != nil {
+		t.Fatalf("trysqltest: starting sandbox: %s", err.Error())
+	}
+	t.Cleanup(func() {
+		dumpLogs(t, ts)
+		err := ts.TearDown()
+		if err != nil {
+			t.Errorf("trysqltest: tearing down %s: %s", ts.Name(), err.Error())
+		}
+	})
+	return ts
+}
+
+// arguments keeps sandboxes quiet and on a free port each, so that tests and packages can run in
+// parallel, unless the caller's own args say otherwise, since later flags win
+func (o *options) arguments() []string {
+	args := []string{"--reporter", "none", "--port", "0"}
+	args = append(args, o.args...)
+	if len(o.shared) > 0 {
+		args = append(args, "--name", o.shared, "--lifetime", trysql.LifetimeDetached)
+	}
+	return args
+}
+
+func sharedSandbox(ctx context.Context, t testing.TB, args []string, name string) *trysql.TrySql {
+	shared.Lock()
+	defer shared.Unlock()
+	ts, ok := shared.sandboxes[name]
+	if ok {
+		return ts
+	}
+	ts, err := trysql.InitialiseContext(ctx, args)
+	if err != nil {
+		t.Fatalf("trysqltest: starting shared sandbox %s: %s", name, err.Error())
+	}
+	shared.sandboxes[name] = ts
+	return ts
+}
+
+// available checks that the runtime the sandbox would be started with answers at all, layering
+// the args over the config file and environment as starting it does
+func available(ctx context.Context, args []string) error {
+	confs, err := configs.Load(args)
+	if err != nil {
+		return err
+	}
+	rt, err := runtimes.New(confs)
+	if err != nil {
+		return err
+	}
+	_, err = rt.Version(ctx)
+	return err
+}
+
+func dumpLogs(t testing.TB, ts *trysql.TrySql) {
+	if !t.Failed() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	logs, err := ts.Logs(ctx, logTail)
+	if err != nil {
+		t.Logf("trysqltest: reading the log of %s: %s", ts.Name(), err.Error())
+		return
+	}
+	t.Logf("trysqltest: last %d lines of the log of %s:\n%s", logTail, ts.Name(), logs)
+}
+
+// testContext ends shortly before the test binary's deadline, when there is one, so that a
+// sandbox that will not start fails the test rather than panicking the binary
+func testContext(t testing.TB) (context.Context, context.CancelFunc) {
+	deadliner, ok := t.(interface{ Deadline() (time.Time, bool) })
+	if !ok {
+		return context.WithCancel(context.Background())
+	}
+	deadline, ok := deadliner.Deadline()
+	if !ok {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), deadline.Add(-5*time.Second))
+}
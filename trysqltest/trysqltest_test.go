@@ -0,0 +1,44 @@
+package trysqltest
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestArguments(t *testing.T) {
+	o := &options{}
+	for _, opt := range []Option{WithEngine("postgres"), WithVersion("16"), Shared("fixtures")} {
+		opt(o)
+	}
+	expects := "--reporter none --port 0 --engine postgres --version 16 --name fixtures --lifetime detached"
+	if strings.Join(o.arguments(), " ") != expects {
+		t.Errorf("expected '%s', got '%s'", expects, strings.Join(o.arguments(), " "))
+	}
+}
+
+func TestAvailableReadsEnvironment(t *testing.T) {
+	t.Setenv("TRYSQL_RUNTIME", "lxc")
+	err := available(context.Background(), []string{"--reporter", "none"})
+	if err == nil || !strings.Contains(err.Error(), "unknown container runtime 'lxc'") {
+		t.Errorf("expected the runtime to be chosen as when starting the sandbox, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	ts := New(t)
+	result, err := ts.QueryRows("SELECT 1 AS one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, _ := result.Value(0, "one")
+	if one != int64(1) {
+		t.Errorf("expected 1, got %v", one)
+	}
+}
+
+func TestNewSkipsWithoutRuntime(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix:///nonexistent/docker.sock")
+	New(t, WithArgs("--runtime", "docker"))
+	t.Errorf("expected the test to be skipped without a reachable runtime")
+}
@@ -1,24 +1,30 @@
 package utils
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"math/rand"
+	"math/big"
 	"os"
-	"os/exec"
-	"strconv"
 	"strings"
 	"testing"
 	"time"
 )
 
-const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+// The characters generated passwords are made of. Symbols leaves out quotes, backslashes and the
+// characters that would need escaping in a URI's userinfo
+const (
+	Letters      = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Alphanumeric = Letters + "0123456789"
+	Symbols      = Alphanumeric + "-_.~!*()"
+)
 
-func GetProcessOwner() (string, error) {
-	stdout, err := exec.Command("ps", "-o", "user=", "-p", strconv.Itoa(os.Getpid())).Output()
-	if err != nil {
-		return "", err
-	}
-	return string(stdout), nil
+// Charsets maps the names of the password charsets to their characters
+var Charsets = map[string]string{
+	"letters":      Letters,
+	"alphanumeric": Alphanumeric,
+	"symbols":      Symbols,
 }
 
 func GetErrors(errs []error) error {
@@ -53,16 +59,98 @@ func HandelPanic(t *testing.T) {
 	}
 }
 
+// MakePass generates a 32 character alphanumeric password. It panics if the system's source of
+// randomness fails, which GeneratePassword returns as an error instead
 func MakePass() (string, []byte) {
-	rand.Seed(time.Now().Unix())
-	bytes := randBytes(32)
-	return string(bytes), bytes
+	password, err := GeneratePassword(32, Alphanumeric)
+	if err != nil {
+		panic(err)
+	}
+	return password, []byte(password)
+}
+
+// GeneratePassword draws length characters uniformly from charset using crypto/rand
+func GeneratePassword(length int, charset string) (string, error) {
+	if len(charset) < 1 {
+		return "", fmt.Errorf("cannot generate a password from an empty charset")
+	}
+	password := make([]byte, length)
+	max := big.NewInt(int64(len(charset)))
+	for i := range password {
+		n, err := crand.Int(crand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = charset[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// Redacted stands in for secrets removed from errors and logs
+const Redacted = "********"
+
+// Redact replaces every occurrence of the secrets in text. Empty secrets are skipped
+func Redact(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if len(secret) > 0 {
+			text = strings.ReplaceAll(text, secret, Redacted)
+		}
+	}
+	return text
 }
 
-func randBytes(n int) []byte {
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letterBytes[rand.Intn(len(letterBytes))]
+// Redactable is an error whose fields may hold secrets, which it can return a redacted copy of
+type Redactable interface {
+	error
+	Redact(secrets ...string) error
+}
+
+// RedactError hides the secrets in err and in every error it wraps. Redactable errors are always
+// replaced by their redacted copies, since their fields may hold secrets their messages do not
+// show, so errors.As only ever finds the copies. Other errors whose chain holds no secret are
+// returned as they are, and the rest are replaced by their redacted messages, unwrapping to the
+// redacted errors they wrapped
+func RedactError(err error, secrets ...string) error {
+	redacted, _ := redactError(err, secrets...)
+	return redacted
+}
+
+// redactError reports whether anything in err's chain had to be redacted
+func redactError(err error, secrets ...string) (error, bool) {
+	if err == nil {
+		return nil, false
+	}
+	redactable, ok := err.(Redactable)
+	if ok {
+		return redactable.Redact(secrets...), true
+	}
+	message := Redact(err.Error(), secrets...)
+	cause, changed := redactError(errors.Unwrap(err), secrets...)
+	if !changed && message == err.Error() {
+		return err, false
+	}
+	return &redactedError{err: cause, message: message}, true
+}
+
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// UniqueName appends a random suffix to the prefix, for naming resources that must not collide across processes
+func UniqueName(prefix string) string {
+	suffix := make([]byte, 6)
+	_, err := crand.Read(suffix)
+	if err != nil {
+		return fmt.Sprintf("%s-%d-%d", prefix, os.Getpid(), time.Now().UnixNano())
 	}
-	return b
+	return prefix + "-" + hex.EncodeToString(suffix)
 }
@@ -1,15 +1,12 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 )
 
-func TestGetProcessOwner(t *testing.T) {
-	defer HandelPanic(t)
-	GetProcessOwner()
-}
-
 func TestGetErrors(t *testing.T) {
 	defer HandelPanic(t)
 	var errs []error
@@ -49,6 +46,74 @@ func TestMakePass(t *testing.T) {
 	}
 }
 
+func TestGeneratePassword(t *testing.T) {
+	first, err := GeneratePassword(64, Symbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := GeneratePassword(64, Symbols)
+	if len(first) != 64 || first == second {
+		t.Errorf("expected distinct passwords of 64 characters, got '%s' and '%s'", first, second)
+	}
+	for _, char := range first {
+		if !strings.ContainsRune(Symbols, char) {
+			t.Errorf("expected only characters of the charset, got '%c'", char)
+		}
+	}
+	letters, _ := GeneratePassword(40, Letters)
+	if strings.Trim(letters, Letters) != "" {
+		t.Errorf("expected only letters, got '%s'", letters)
+	}
+	_, err = GeneratePassword(8, "")
+	if err == nil {
+		t.Errorf("expected an empty charset to be refused")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	defer HandelPanic(t)
+	if Redact("user s3cret, app hunter2", "s3cret", "", "hunter2") != "user ********, app ********" {
+		t.Errorf("expected both secrets to be redacted")
+	}
+	cause := fmt.Errorf("exit status 1")
+	err := RedactError(fmt.Errorf("mysql --password=s3cret: %w", cause), "s3cret")
+	if err.Error() != "mysql --password=********: exit status 1" || !errors.Is(err, cause) {
+		t.Errorf("expected the message to be redacted and the cause kept, got '%s'", err.Error())
+	}
+	if RedactError(cause, "s3cret") != cause || RedactError(nil, "s3cret") != nil {
+		t.Errorf("expected errors without secrets to be returned as they are")
+	}
+	err = RedactError(fmt.Errorf("query failed: %w", &secretError{field: "s3cret"}), "s3cret")
+	secret := &secretError{}
+	if !errors.As(err, &secret) || secret.field != Redacted {
+		t.Errorf("expected the wrapped error's fields to be redacted, got '%s'", secret.field)
+	}
+}
+
+// secretError keeps a secret in a field its message does not print
+type secretError struct {
+	field string
+}
+
+func (e *secretError) Error() string {
+	return "secret error"
+}
+
+func (e *secretError) Redact(secrets ...string) error {
+	return &secretError{field: Redact(e.field, secrets...)}
+}
+
 func triggerPanic(nt *testing.T) {
 	panic(fmt.Errorf("test panic"))
 }
+
+func TestUniqueName(t *testing.T) {
+	first := UniqueName("TrySql")
+	second := UniqueName("TrySql")
+	if !strings.HasPrefix(first, "TrySql-") {
+		t.Errorf("expected name to start with 'TrySql-', got '%s'", first)
+	}
+	if first == second {
+		t.Errorf("expected unique names, got '%s' twice", first)
+	}
+}